@@ -0,0 +1,277 @@
+package main
+
+import (
+	"crypto/rand"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Agent runs a single prompt with a coding agent and returns its output
+type Agent interface {
+	Run(timeoutSeconds int, systemPrompt string, prompt string) (*ClaudeResult, error)
+}
+
+// Placeholders substituted into agent_command
+const (
+	AgentPromptPlaceholder       = "{{prompt}}"
+	AgentSystemPromptPlaceholder = "{{system_prompt}}"
+)
+
+// claudeAgent runs prompts with the Claude CLI (the default backend)
+type claudeAgent struct{}
+
+// Run invokes claude with the system prompt and prompt
+func (claudeAgent) Run(timeoutSeconds int, systemPrompt string, prompt string) (*ClaudeResult, error) {
+	// Check if claude command exists
+	if _, err := exec.LookPath("claude"); err != nil {
+		return nil, fmt.Errorf("claude command not found in PATH. Please ensure the Claude CLI is installed and available")
+	}
+
+	args := []string{"--system-prompt", systemPrompt}
+	args = append(args, claudePermissionArgs()...)
+	args = append(args, claudeSessionArgs()...)
+	args = append(args, "-p", prompt)
+	result, err := runAgentProcess(timeoutSeconds, "claude", args...)
+	if agentSessionID != "" {
+		if err == nil {
+			agentSessionStarted = true
+		} else if !agentSessionStarted {
+			// The session may or may not exist after a failed first step; retry under a fresh ID
+			startAgentSession()
+		}
+	}
+	return result, err
+}
+
+// Session reuse within a workflow pass (persist_session): the first step creates the session with
+// --session-id, later steps continue it with --resume
+var (
+	agentSessionID      string
+	agentSessionStarted bool
+)
+
+// endAgentSession makes later agent runs cold-start again
+func endAgentSession() {
+	agentSessionID, agentSessionStarted = "", false
+}
+
+// startAgentSession starts a new Claude session for the steps that follow when persist_session is set
+func startAgentSession() {
+	endAgentSession()
+	if !ralphConfig.PersistSession {
+		return
+	}
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		statusf("⚠️  Warning: failed to create a session ID, steps will cold-start: %v\n", err)
+		return
+	}
+	b[6] = b[6]&0x0f | 0x40 // version 4
+	b[8] = b[8]&0x3f | 0x80 // RFC 4122 variant
+	agentSessionID = fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// claudeSessionArgs returns the Claude CLI session flags: by default each step is a cold start
+// (--no-session-persistence); with persist_session the steps of a workflow pass share one session
+func claudeSessionArgs() []string {
+	if agentSessionID == "" {
+		return []string{"--no-session-persistence"}
+	}
+	if agentSessionStarted {
+		return []string{"--resume", agentSessionID}
+	}
+	return []string{"--session-id", agentSessionID}
+}
+
+// claudePermissionArgs returns the Claude CLI permission flags: allowed_tools / permission_mode from config when set,
+// otherwise --dangerously-skip-permissions (the default, which lets Claude run any tool unattended)
+func claudePermissionArgs() []string {
+	if len(ralphConfig.AllowedTools) == 0 && ralphConfig.PermissionMode == "" {
+		return []string{"--dangerously-skip-permissions"}
+	}
+
+	var args []string
+	if ralphConfig.PermissionMode != "" {
+		args = append(args, "--permission-mode", ralphConfig.PermissionMode)
+	}
+	if len(ralphConfig.AllowedTools) > 0 {
+		args = append(args, "--allowedTools", strings.Join(ralphConfig.AllowedTools, ","))
+	}
+	return args
+}
+
+// commandAgent runs prompts with a user-defined command template (agent_command in .ralph/config.toml).
+// Output is captured as plain text; completion and blocking are detected from the <promise> markers.
+type commandAgent struct {
+	Template string
+}
+
+// Run expands the template and invokes the command
+func (a commandAgent) Run(timeoutSeconds int, systemPrompt string, prompt string) (*ClaudeResult, error) {
+	args, err := agentCommandArgs(a.Template, systemPrompt, prompt)
+	if err != nil {
+		return nil, err
+	}
+	if len(args) == 0 {
+		return nil, fmt.Errorf("agent_command is empty")
+	}
+	if _, err := exec.LookPath(args[0]); err != nil {
+		return nil, fmt.Errorf("agent command %s not found in PATH (agent_command in %s)", args[0], RalphConfigFile)
+	}
+
+	return runAgentProcess(timeoutSeconds, args[0], args[1:]...)
+}
+
+// agentCommandArgs splits the template into arguments and substitutes the placeholders in each one,
+// so a prompt always stays a single argument no matter what it contains
+func agentCommandArgs(template string, systemPrompt string, prompt string) ([]string, error) {
+	fields, err := splitCommandTemplate(template)
+	if err != nil {
+		return nil, err
+	}
+	args := make([]string, 0, len(fields))
+	for _, field := range fields {
+		field = strings.ReplaceAll(field, AgentSystemPromptPlaceholder, systemPrompt)
+		field = strings.ReplaceAll(field, AgentPromptPlaceholder, prompt)
+		args = append(args, field)
+	}
+	return args, nil
+}
+
+// splitCommandTemplate splits agent_command into arguments the way a POSIX shell splits words: whitespace
+// separates arguments, single quotes keep everything literal, double quotes keep whitespace (a backslash
+// escapes \, " and $ inside them), and a backslash outside quotes escapes the next character. Nothing else
+// of the shell applies: no variables, globs, pipes or redirections.
+func splitCommandTemplate(template string) ([]string, error) {
+	var args []string
+	var current strings.Builder
+	inWord := false
+	runes := []rune(template)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case r == '\'':
+			inWord = true
+			end := i + 1
+			for end < len(runes) && runes[end] != '\'' {
+				end++
+			}
+			if end == len(runes) {
+				return nil, fmt.Errorf("agent_command in %s has an unterminated single quote", RalphConfigFile)
+			}
+			current.WriteString(string(runes[i+1 : end]))
+			i = end
+		case r == '"':
+			inWord = true
+			i++
+			for ; i < len(runes) && runes[i] != '"'; i++ {
+				if runes[i] == '\\' && i+1 < len(runes) && strings.ContainsRune(`\"$`, runes[i+1]) {
+					i++
+				}
+				current.WriteRune(runes[i])
+			}
+			if i == len(runes) {
+				return nil, fmt.Errorf("agent_command in %s has an unterminated double quote", RalphConfigFile)
+			}
+		case r == '\\':
+			inWord = true
+			if i+1 < len(runes) {
+				i++
+				current.WriteRune(runes[i])
+			}
+		case r == ' ' || r == '\t' || r == '\n':
+			if inWord {
+				args = append(args, current.String())
+				current.Reset()
+				inWord = false
+			}
+		default:
+			inWord = true
+			current.WriteRune(r)
+		}
+	}
+	if inWord {
+		args = append(args, current.String())
+	}
+	return args, nil
+}
+
+// activeAgent returns the configured agent backend
+func activeAgent() Agent {
+	if ralphConfig.AgentCommand != "" {
+		return commandAgent{Template: ralphConfig.AgentCommand}
+	}
+	return claudeAgent{}
+}
+
+// claudeVersion is the Claude CLI version detected at startup ("" until checked or when unknown)
+var claudeVersion string
+
+// claudeVersionChecked makes checkClaudeVersion run once per process (manager mode runs the loop per ticket)
+var claudeVersionChecked bool
+
+// versionPattern matches the first x.y.z version number in `claude --version` output
+var versionPattern = regexp.MustCompile(`(\d+)\.(\d+)\.(\d+)`)
+
+// parseVersion extracts the first x.y.z version number from s
+func parseVersion(s string) ([3]int, bool) {
+	var version [3]int
+	matches := versionPattern.FindStringSubmatch(s)
+	if matches == nil {
+		return version, false
+	}
+	for i := range version {
+		version[i], _ = strconv.Atoi(matches[i+1])
+	}
+	return version, true
+}
+
+// compareVersions returns -1, 0 or 1 as a is older than, equal to or newer than b
+func compareVersions(a, b [3]int) int {
+	for i := range a {
+		if a[i] != b[i] {
+			if a[i] < b[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+// checkClaudeVersion runs `claude --version` once, reports the version and warns when it is outside
+// ClaudeVersionMin..ClaudeVersionBelow. Skipped with agent_command or skip_claude_version_check.
+func checkClaudeVersion() {
+	if claudeVersionChecked || ralphConfig.AgentCommand != "" {
+		return
+	}
+	claudeVersionChecked = true
+
+	ctx, cancel := contextWithTimeout(10)
+	defer cancel()
+	output, err := exec.CommandContext(ctx, "claude", "--version").Output()
+	if err != nil {
+		statusf("⚠️  Warning: could not determine the Claude CLI version (claude --version: %v)\n", err)
+		return
+	}
+	claudeVersion = strings.TrimSpace(string(output))
+	statusf("ℹ️  Claude CLI %s\n", claudeVersion)
+	if ralphConfig.SkipClaudeVersionCheck {
+		return
+	}
+
+	version, ok := parseVersion(claudeVersion)
+	if !ok {
+		statusf("⚠️  Warning: could not parse the Claude CLI version from %q\n", claudeVersion)
+		return
+	}
+	minVersion, _ := parseVersion(ClaudeVersionMin)
+	belowVersion, _ := parseVersion(ClaudeVersionBelow)
+	if compareVersions(version, minVersion) < 0 || compareVersions(version, belowVersion) >= 0 {
+		statusf("⚠️  Warning: Claude CLI %s is outside the range this Ralph version is known to work with (>= %s, < %s).\n", claudeVersion, ClaudeVersionMin, ClaudeVersionBelow)
+		fmt.Printf("   Steps may fail with unexpected flag or output errors; upgrade or downgrade the CLI, or set skip_claude_version_check = true in %s.\n", RalphConfigFile)
+	}
+}
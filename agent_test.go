@@ -0,0 +1,38 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAgentCommandArgs(t *testing.T) {
+	tests := []struct {
+		template string
+		want     []string
+	}{
+		{`aider --yes --message {{prompt}}`, []string{"aider", "--yes", "--message", "do it now"}},
+		{`agent "{{prompt}}"`, []string{"agent", "do it now"}},
+		{`agent --system '{{system_prompt}}' -m {{prompt}}`, []string{"agent", "--system", "be brief", "-m", "do it now"}},
+		{`sh -c 'cat > out.txt' stub {{prompt}}`, []string{"sh", "-c", "cat > out.txt", "stub", "do it now"}},
+		{`agent --tag "a \"b\" c" x\ y`, []string{"agent", "--tag", `a "b" c`, "x y"}},
+		{`agent --empty "" {{prompt}}`, []string{"agent", "--empty", "", "do it now"}},
+	}
+	for _, tt := range tests {
+		got, err := agentCommandArgs(tt.template, "be brief", "do it now")
+		if err != nil {
+			t.Errorf("agentCommandArgs(%q): %v", tt.template, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("agentCommandArgs(%q) = %q, want %q", tt.template, got, tt.want)
+		}
+	}
+}
+
+func TestAgentCommandArgsUnterminatedQuote(t *testing.T) {
+	for _, template := range []string{`agent '{{prompt}}`, `agent "{{prompt}}`} {
+		if _, err := agentCommandArgs(template, "", "p"); err == nil {
+			t.Errorf("agentCommandArgs(%q) succeeded, want an unterminated quote error", template)
+		}
+	}
+}
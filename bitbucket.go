@@ -0,0 +1,240 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// BitbucketPRCreator creates pull requests using the Bitbucket Cloud REST API
+type BitbucketPRCreator struct {
+	Workspace string
+	RepoSlug  string
+	Token     string // Repository/workspace access token, or app password when Username is set
+	Username  string // Optional: Bitbucket username for app-password (basic) authentication
+	BaseURL   string
+}
+
+// bitbucketErrorResponse is the error envelope returned by the Bitbucket API
+type bitbucketErrorResponse struct {
+	Error struct {
+		Message string `json:"message"`
+		Detail  string `json:"detail"`
+	} `json:"error"`
+}
+
+// bitbucketPullRequest is the subset of a Bitbucket pull request we use
+type bitbucketPullRequest struct {
+	ID    int `json:"id"`
+	Links struct {
+		HTML struct {
+			Href string `json:"href"`
+		} `json:"html"`
+	} `json:"links"`
+}
+
+// NewBitbucketPRCreator creates a Bitbucket PR creator for the given remote URL.
+// Credentials come from the config, falling back to BITBUCKET_TOKEN / BITBUCKET_USERNAME.
+func NewBitbucketPRCreator(remoteURL string, config *LinearConfig) (*BitbucketPRCreator, error) {
+	workspace, repoSlug, err := parseBitbucketRemote(remoteURL)
+	if err != nil {
+		return nil, err
+	}
+
+	token := ""
+	username := ""
+	if config != nil {
+		token = config.BitbucketToken
+		username = config.BitbucketUsername
+	}
+	if token == "" {
+		token = os.Getenv("BITBUCKET_TOKEN")
+	}
+	if username == "" {
+		username = os.Getenv("BITBUCKET_USERNAME")
+	}
+
+	return &BitbucketPRCreator{
+		Workspace: workspace,
+		RepoSlug:  repoSlug,
+		Token:     token,
+		Username:  username,
+		BaseURL:   BitbucketAPIEndpoint,
+	}, nil
+}
+
+// parseBitbucketRemote extracts the workspace and repository slug from a Bitbucket remote URL.
+// Supports git@bitbucket.org:ws/repo.git, ssh://git@bitbucket.org/ws/repo.git and https://[user@]bitbucket.org/ws/repo.git
+func parseBitbucketRemote(remoteURL string) (string, string, error) {
+	pattern := regexp.MustCompile(`bitbucket\.org[:/]([^/]+)/([^/]+?)(?:\.git)?/?$`)
+	matches := pattern.FindStringSubmatch(strings.TrimSpace(remoteURL))
+	if len(matches) < 3 {
+		return "", "", fmt.Errorf("could not parse Bitbucket workspace and repository from remote URL: %s", remoteURL)
+	}
+	return matches[1], matches[2], nil
+}
+
+// Name returns the provider name
+func (b *BitbucketPRCreator) Name() string {
+	return "Bitbucket"
+}
+
+// Validate checks that credentials are configured and can read the repository
+func (b *BitbucketPRCreator) Validate() error {
+	if b.Token == "" {
+		return fmt.Errorf("Bitbucket token is not configured. Set bitbucket_token in the config file or the BITBUCKET_TOKEN environment variable")
+	}
+
+	if b.Username != "" {
+		statusf("ℹ️  Using Bitbucket app password for %s (%s)\n", b.Username, maskSecret(b.Token))
+	} else {
+		statusf("ℹ️  Using Bitbucket access token (%s)\n", maskSecret(b.Token))
+	}
+
+	if _, err := b.doRequest("GET", b.repositoryURL(), nil); err != nil {
+		return fmt.Errorf("failed to access Bitbucket repository %s/%s: %v", b.Workspace, b.RepoSlug, err)
+	}
+	return nil
+}
+
+// CreatePullRequest opens a pull request via the Bitbucket API
+func (b *BitbucketPRCreator) CreatePullRequest(title, body, base, head string) (string, error) {
+	payload := map[string]interface{}{
+		"title":       title,
+		"description": body,
+		"source": map[string]interface{}{
+			"branch": map[string]string{"name": head},
+		},
+		"destination": map[string]interface{}{
+			"branch": map[string]string{"name": base},
+		},
+		"close_source_branch": false,
+	}
+
+	respBody, err := b.doRequest("POST", b.repositoryURL()+"/pullrequests", payload)
+	if err != nil {
+		// Bitbucket rejects duplicate pull requests; look up the existing one like the GitHub path does
+		if prURL, findErr := b.FindPullRequest(head); findErr == nil && prURL != "" {
+			statusf("ℹ️  Pull request already exists: %s\n", prURL)
+			return prURL, nil
+		}
+		return "", fmt.Errorf("failed to create pull request: %v", err)
+	}
+
+	var pr bitbucketPullRequest
+	if err := json.Unmarshal(respBody, &pr); err != nil {
+		return "", fmt.Errorf("failed to parse pull request response: %v", err)
+	}
+	if pr.Links.HTML.Href == "" {
+		return fmt.Sprintf("https://bitbucket.org/%s/%s/pull-requests/%d", b.Workspace, b.RepoSlug, pr.ID), nil
+	}
+	return pr.Links.HTML.Href, nil
+}
+
+// bbqlString quotes s as a string literal for a Bitbucket query (BBQL), escaping backslashes and double quotes
+func bbqlString(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// FindPullRequest returns the URL of an open pull request whose source is the given branch
+func (b *BitbucketPRCreator) FindPullRequest(head string) (string, error) {
+	query := url.Values{}
+	query.Set("q", fmt.Sprintf(`source.branch.name=%s AND state="OPEN"`, bbqlString(head)))
+	respBody, err := b.doRequest("GET", b.repositoryURL()+"/pullrequests?"+query.Encode(), nil)
+	if err != nil {
+		return "", err
+	}
+
+	var result struct {
+		Values []bitbucketPullRequest `json:"values"`
+	}
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return "", fmt.Errorf("failed to parse pull request list: %v", err)
+	}
+	if len(result.Values) == 0 {
+		return "", nil
+	}
+	return result.Values[0].Links.HTML.Href, nil
+}
+
+// bitbucketPRIDPattern extracts the pull request ID from a Bitbucket pull request URL
+var bitbucketPRIDPattern = regexp.MustCompile(`/pull-requests/([0-9]+)`)
+
+// CommentPullRequest adds a comment to a pull request via the Bitbucket API
+func (b *BitbucketPRCreator) CommentPullRequest(prURL, body string) error {
+	matches := bitbucketPRIDPattern.FindStringSubmatch(prURL)
+	if matches == nil {
+		return fmt.Errorf("cannot find the pull request ID in %s", prURL)
+	}
+	payload := map[string]interface{}{
+		"content": map[string]string{"raw": body},
+	}
+	if _, err := b.doRequest("POST", b.repositoryURL()+"/pullrequests/"+matches[1]+"/comments", payload); err != nil {
+		return fmt.Errorf("failed to comment on pull request: %v", err)
+	}
+	return nil
+}
+
+// repositoryURL returns the API URL for the configured repository
+func (b *BitbucketPRCreator) repositoryURL() string {
+	return fmt.Sprintf("%s/repositories/%s/%s", b.BaseURL, url.PathEscape(b.Workspace), url.PathEscape(b.RepoSlug))
+}
+
+// doRequest performs an authenticated Bitbucket API request and returns the response body
+func (b *BitbucketPRCreator) doRequest(method, requestURL string, payload interface{}) ([]byte, error) {
+	var reqBody io.Reader
+	if payload != nil {
+		jsonData, err := json.Marshal(payload)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal request: %v", err)
+		}
+		reqBody = bytes.NewBuffer(jsonData)
+	}
+
+	req, err := http.NewRequest(method, requestURL, reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %v", err)
+	}
+
+	req.Header.Set("Accept", "application/json")
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if b.Username != "" {
+		req.SetBasicAuth(b.Username, b.Token)
+	} else {
+		req.Header.Set("Authorization", "Bearer "+b.Token)
+	}
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %v", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var apiErr bitbucketErrorResponse
+		if json.Unmarshal(body, &apiErr) == nil && apiErr.Error.Message != "" {
+			if apiErr.Error.Detail != "" {
+				return nil, fmt.Errorf("HTTP %d: %s (%s)", resp.StatusCode, apiErr.Error.Message, apiErr.Error.Detail)
+			}
+			return nil, fmt.Errorf("HTTP %d: %s", resp.StatusCode, apiErr.Error.Message)
+		}
+		return nil, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+
+	return body, nil
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestFindPullRequestEscapesTheBranch(t *testing.T) {
+	var query string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query().Get("q")
+		w.Write([]byte(`{"values": [{"links": {"html": {"href": "https://bitbucket.org/acme/app/pull-requests/7"}}}]}`))
+	}))
+	defer server.Close()
+
+	creator := &BitbucketPRCreator{Workspace: "acme", RepoSlug: "app", Token: "token", BaseURL: server.URL}
+	prURL, err := creator.FindPullRequest(`ralph/fix-"quoted"-C:\path`)
+	if err != nil {
+		t.Fatalf("FindPullRequest: %v", err)
+	}
+	if prURL != "https://bitbucket.org/acme/app/pull-requests/7" {
+		t.Errorf("FindPullRequest() = %q", prURL)
+	}
+	want := `source.branch.name="ralph/fix-\"quoted\"-C:\\path" AND state="OPEN"`
+	if query != want {
+		t.Errorf("query q = %s, want %s", query, want)
+	}
+}
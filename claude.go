@@ -1,35 +1,53 @@
 package main
 
 import (
+	"bufio"
+	"bytes"
 	"context"
 	"fmt"
 	"io"
+	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
+	"sync/atomic"
 	"time"
 )
 
 type ClaudeResult struct {
-	Output   string
-	Success  bool
-	Blocked  bool
-	Complete bool
+	Output    string
+	Success   bool
+	Blocked   bool
+	Complete  bool
+	Compliant bool // <promise>COMPLIANT</promise> emitted (guardrail verification)
+	Truncated bool // Output was cut off because the step deadline fired mid-run
+
+	Guardrails *GuardrailReport // Outcome of the post-implementation guardrail check (set by workflow1PlanAndImplement)
 }
 
+// runClaude runs a prompt through the active agent backend (the Claude CLI unless agent_command is configured)
 func runClaude(timeoutSeconds int, systemPrompt string, prompt string) (*ClaudeResult, error) {
-	// Check if claude command exists
-	if _, err := exec.LookPath("claude"); err != nil {
-		return nil, fmt.Errorf("claude command not found in PATH. Please ensure the Claude CLI is installed and available")
+	// Paths in .ralph/.ralphignore never reach the agent's context, whichever prompt referenced them
+	prompt = stripRalphIgnoredRefs(prompt)
+	// Files a built-in prompt references but this project doesn't have (CLAUDE.md, GUARDRAILS.md, ...)
+	prompt = dereferenceMissingRefs(prompt)
+	// A prompt that clearly won't fit the context window fails here with guidance instead of as an opaque API error
+	if err := checkPromptBudget(systemPrompt, prompt); err != nil {
+		return nil, err
 	}
 
+	started := clock.Now()
+	result, err := activeAgent().Run(timeoutSeconds, systemPrompt, prompt)
+	recordAgentCall(clock.Now().Sub(started), err != nil || result == nil || !result.Success)
+	return result, err
+}
+
+// runAgentProcess runs an agent command, streams its plain-text output, and detects the <promise> markers
+func runAgentProcess(timeoutSeconds int, name string, args ...string) (*ClaudeResult, error) {
 	ctx, cancel := contextWithTimeout(timeoutSeconds)
 	defer cancel()
 
-	cmd := exec.CommandContext(ctx, "claude",
-		"--system-prompt", systemPrompt,
-		"--dangerously-skip-permissions",
-		"--no-session-persistence",
-		"-p", prompt)
+	cmd := exec.CommandContext(ctx, name, args...)
 
 	stdout, err := cmd.StdoutPipe()
 	if err != nil {
@@ -44,16 +62,57 @@ func runClaude(timeoutSeconds int, systemPrompt string, prompt string) (*ClaudeR
 		return nil, fmt.Errorf("failed to start command: %v", err)
 	}
 
-	// Plain text output: read stdout and stderr
-	stdoutBytes, _ := io.ReadAll(stdout)
-	stderrBytes, _ := io.ReadAll(stderr)
-	stdoutStr := strings.TrimSpace(string(stdoutBytes))
-	stderrStr := strings.TrimSpace(string(stderrBytes))
+	// Drain stderr concurrently so a chatty stderr can't fill its pipe buffer and block the process
+	// while stdout is still streaming (both pipes must be fully read before cmd.Wait closes them)
+	var stderrBuf bytes.Buffer
+	stderrDone := make(chan struct{})
+	go func() {
+		io.Copy(&stderrBuf, stderr)
+		close(stderrDone)
+	}()
+
+	// Heartbeat while Claude is silent (suppressed in quiet mode)
+	var lastOutput atomic.Int64
+	lastOutput.Store(clock.Now().UnixNano())
+	heartbeatDone := make(chan struct{})
+	if !cliOptions.Quiet {
+		go claudeHeartbeat(clock.Now(), &lastOutput, heartbeatDone)
+	}
+
+	// Raw stdout tee for debugging (--raw-stream-file); nil when disabled
+	rawFile := openRawStreamFile()
+	if rawFile != nil {
+		defer rawFile.Close()
+	}
 
-	// Echo stdout to the user
-	if stdoutStr != "" {
-		fmt.Println(stdoutStr)
+	// Stream stdout line by line, echoing it to the user (suppressed in quiet mode; the output is still captured in the result).
+	// Lines are echoed exactly as received, with no line-ending rewriting, so piped/redirected output stays clean.
+	var stdoutBuf strings.Builder
+	reader := bufio.NewReader(stdout)
+	for {
+		line, readErr := reader.ReadString('\n')
+		if line != "" {
+			stdoutBuf.WriteString(line)
+			if rawFile != nil {
+				rawFile.WriteString(line)
+			}
+			lastOutput.Store(clock.Now().UnixNano())
+			if !cliOptions.Quiet {
+				fmt.Print(line)
+			}
+		}
+		if readErr != nil {
+			if line != "" && !cliOptions.Quiet {
+				fmt.Println()
+			}
+			break
+		}
 	}
+	close(heartbeatDone)
+	<-stderrDone
+
+	stdoutStr := strings.TrimSpace(stdoutBuf.String())
+	stderrStr := strings.TrimSpace(stderrBuf.String())
 
 	err = cmd.Wait()
 
@@ -65,6 +124,7 @@ func runClaude(timeoutSeconds int, systemPrompt string, prompt string) (*ClaudeR
 	if err != nil {
 		if ctx.Err() == context.DeadlineExceeded {
 			result.Success = false
+			result.Truncated = true
 			details := extractErrorDetails(stderrStr, "", stdoutStr, err)
 			details.Category = "timeout"
 			details.Message = fmt.Sprintf("Request timeout after %d seconds", timeoutSeconds)
@@ -75,12 +135,114 @@ func runClaude(timeoutSeconds int, systemPrompt string, prompt string) (*ClaudeR
 		return result, formatClaudeError(details)
 	}
 
+	// A successful run that printed nothing usually means the CLI's output format changed under us
+	if result.Output == "" {
+		warnEmptyAgentOutput(name, stderrStr)
+	}
+
 	result.Blocked = strings.Contains(result.Output, "<promise>BLOCKED</promise>")
 	result.Complete = strings.Contains(result.Output, "<promise>COMPLETE</promise>")
+	result.Compliant = strings.Contains(result.Output, "<promise>COMPLIANT</promise>")
 
 	return result, nil
 }
 
+// rawStreamStep names the step whose agent run is in progress (set by executeStepWithRetry), used in raw stream file names
+var rawStreamStep string
+
+// rawStreamCount numbers the raw stream files written during this run
+var rawStreamCount int
+
+// openRawStreamFile creates the next raw stream file for --raw-stream-file: <stem>-NNN-<step-slug><ext>.
+// Returns nil when the option is off or the file can't be created (a warning is printed).
+// Files are created 0600 since they hold the agent's complete, unfiltered output.
+func openRawStreamFile() *os.File {
+	if cliOptions.RawStreamFile == "" {
+		return nil
+	}
+
+	rawStreamCount++
+	step := slugify(strings.TrimSuffix(rawStreamStep, "..."))
+	if step == "" {
+		step = "agent"
+	}
+	ext := filepath.Ext(cliOptions.RawStreamFile)
+	stem := strings.TrimSuffix(cliOptions.RawStreamFile, ext)
+	path := fmt.Sprintf("%s-%03d-%s%s", stem, rawStreamCount, step, ext)
+
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			statusf("⚠️  Warning: failed to create raw stream directory: %v\n", err)
+			return nil
+		}
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		statusf("⚠️  Warning: failed to open raw stream file: %v\n", err)
+		return nil
+	}
+	recordRawStreamFile(path)
+	if !cliOptions.Quiet {
+		statusf("📼 Raw output: %s\n", path)
+	}
+	return file
+}
+
+// recordRawStreamFile appends a raw stream file to the index --clean --all removes them from (failures only warn)
+func recordRawStreamFile(path string) {
+	if err := os.MkdirAll(filepath.Dir(RawStreamIndexFile), 0755); err != nil {
+		statusf("⚠️  Warning: failed to record raw stream file: %v\n", err)
+		return
+	}
+	index, err := os.OpenFile(RawStreamIndexFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		statusf("⚠️  Warning: failed to record raw stream file: %v\n", err)
+		return
+	}
+	defer index.Close()
+	fmt.Fprintln(index, path)
+}
+
+// warnEmptyAgentOutput explains a successful agent run that produced no output, with the CLI version and any stderr
+func warnEmptyAgentOutput(name string, stderr string) {
+	statusf("⚠️  Warning: %s exited successfully but produced no output; its output format may not be recognized by this version of Ralph\n", name)
+
+	ctx, cancel := contextWithTimeout(10)
+	defer cancel()
+	if version, err := exec.CommandContext(ctx, name, "--version").Output(); err == nil {
+		fmt.Printf("   %s version: %s (check for a CLI upgrade that changed -p output)\n", name, strings.TrimSpace(string(version)))
+	}
+	if stderr != "" {
+		fmt.Printf("   stderr: %s\n", lastOutputSnippet(stderr))
+	}
+}
+
+// claudeHeartbeat prints a "still working" line whenever Claude has produced no output for ClaudeHeartbeatInterval,
+// so long silent stretches (thinking, tool use) don't look like a hang. It returns when done is closed.
+func claudeHeartbeat(start time.Time, lastOutput *atomic.Int64, done <-chan struct{}) {
+	lastBeat := start
+	for {
+		select {
+		case <-done:
+			return
+		case <-clock.After(ClaudeHeartbeatCheck):
+		}
+
+		now := clock.Now()
+		quietSince := time.Unix(0, lastOutput.Load())
+		if lastBeat.After(quietSince) {
+			quietSince = lastBeat
+		}
+		if now.Sub(quietSince) >= ClaudeHeartbeatInterval {
+			statusf("⏳ Still working (elapsed %dm)\n", int(now.Sub(start).Minutes()))
+			lastBeat = now
+		}
+	}
+}
+
+// contextWithTimeout returns a context that expires after seconds of real time. Process deadlines are enforced
+// by the runtime's timers, so they deliberately don't go through clock: a fake clock's Now would yield
+// deadlines that are already past or far in the future.
 func contextWithTimeout(seconds int) (context.Context, context.CancelFunc) {
 	return context.WithTimeout(context.Background(), time.Duration(seconds)*time.Second)
 }
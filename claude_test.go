@@ -0,0 +1,46 @@
+package main
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunAgentProcessDrainsStdoutAndStderr(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not found in PATH")
+	}
+	previous := cliOptions
+	cliOptions.Quiet = true
+	t.Cleanup(func() { cliOptions = previous })
+
+	// 4 MB on each stream, interleaved in 64 KB chunks: far more than a pipe buffer holds, so the process
+	// stalls until the deadline unless both pipes are read while it runs
+	script := `chunk=$(head -c 65536 /dev/zero | tr '\0' x)
+i=0
+while [ $i -lt 64 ]; do
+	echo "$chunk"
+	echo "$chunk" >&2
+	i=$((i + 1))
+done
+echo "<promise>COMPLETE</promise>"`
+
+	started := time.Now()
+	result, err := runAgentProcess(20, "sh", "-c", script)
+	if err != nil {
+		t.Fatalf("runAgentProcess: %v", err)
+	}
+	if elapsed := time.Since(started); elapsed > 15*time.Second {
+		t.Errorf("took %v, want well within the 20s timeout", elapsed)
+	}
+	if result.Truncated {
+		t.Error("output truncated by the timeout")
+	}
+	if want := 64 * 65537; len(result.Output) < want {
+		t.Errorf("stdout has %d bytes, want at least %d", len(result.Output), want)
+	}
+	if !result.Complete || !strings.HasSuffix(result.Output, "<promise>COMPLETE</promise>") {
+		t.Error("completion marker after the flood was not detected")
+	}
+}
@@ -0,0 +1,32 @@
+package main
+
+import "time"
+
+// Clock abstracts time for timeouts, retry delays and poll intervals so timing-sensitive code
+// can be driven by a fake clock instead of the wall clock
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+	Sleep(d time.Duration)
+}
+
+// realClock is the Clock backed by the time package
+type realClock struct{}
+
+// Now returns the current time
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+// After waits for the duration to elapse and then sends the current time on the returned channel
+func (realClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+// Sleep pauses the current goroutine for the duration
+func (realClock) Sleep(d time.Duration) {
+	time.Sleep(d)
+}
+
+// clock is the Clock used throughout Ralph; replace it to control time
+var clock Clock = realClock{}
@@ -0,0 +1,235 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock is a Clock for tests: time only moves when the code under test sleeps or waits,
+// and Sleep and After return immediately after advancing it
+type fakeClock struct {
+	mu    sync.Mutex
+	now   time.Time
+	waits []time.Duration // Every Sleep/After duration, in order
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	ch := make(chan time.Time, 1)
+	ch <- c.advance(d)
+	return ch
+}
+
+func (c *fakeClock) Sleep(d time.Duration) {
+	c.advance(d)
+}
+
+func (c *fakeClock) advance(d time.Duration) time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	c.waits = append(c.waits, d)
+	return c.now
+}
+
+// elapsed returns the total time the code under test waited
+func (c *fakeClock) elapsed() time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var total time.Duration
+	for _, d := range c.waits {
+		total += d
+	}
+	return total
+}
+
+// useFakeClock swaps in a fake clock for the test
+func useFakeClock(t *testing.T) *fakeClock {
+	t.Helper()
+	fake := newFakeClock()
+	previous := clock
+	clock = fake
+	t.Cleanup(func() { clock = previous })
+	return fake
+}
+
+// inTempDir runs the rest of the test in a fresh temporary directory
+func inTempDir(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	previous, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(previous) })
+	return dir
+}
+
+// withRalphConfig replaces the loop configuration for the test
+func withRalphConfig(t *testing.T, config *RalphConfig) {
+	t.Helper()
+	previous := ralphConfig
+	ralphConfig = config
+	t.Cleanup(func() { ralphConfig = previous })
+}
+
+func TestRunGitWithRetryWaitsOnClock(t *testing.T) {
+	fake := useFakeClock(t)
+	inTempDir(t)
+
+	if err := runGitWithRetry("checkout", "no-such-branch"); err == nil {
+		t.Fatal("runGitWithRetry succeeded outside a repository")
+	}
+	if got, want := fake.elapsed(), time.Duration(GitRetryAttempts-1)*GitRetryDelay; got != want {
+		t.Errorf("retries waited %v, want %v", got, want)
+	}
+}
+
+// approvalProvider is an IssueProvider that reports the approval label after a number of polls
+type approvalProvider struct {
+	IssueProvider
+	approveAfter int // getIssue calls before the label appears; 0 = never
+	polls        int
+	comments     []string
+}
+
+func (p *approvalProvider) getIssue(issueID string) (*Issue, error) {
+	p.polls++
+	issue := &Issue{ID: issueID}
+	if p.approveAfter > 0 && p.polls >= p.approveAfter {
+		issue.Labels.Nodes = append(issue.Labels.Nodes, struct {
+			ID   string
+			Name string
+		}{Name: DefaultPlanApprovalLabel})
+	}
+	return issue, nil
+}
+
+func (p *approvalProvider) addTicketComment(issueID, comment string, usernames []string) error {
+	p.comments = append(p.comments, comment)
+	return nil
+}
+
+func TestAwaitPlanApproval(t *testing.T) {
+	tests := []struct {
+		name         string
+		approveAfter int
+		wantApproved bool
+		wantPolls    int
+	}{
+		{"approved on the third poll", 3, true, 3},
+		{"times out", 0, false, 121}, // 60 minutes at 30s intervals, plus the poll at the deadline
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := useFakeClock(t)
+			dir := inTempDir(t)
+			if err := os.MkdirAll(filepath.Join(dir, ".ralph"), 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(filepath.Join(dir, PlanFile), []byte("# Plan\n"), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			client := &approvalProvider{approveAfter: tt.approveAfter}
+			approved, err := awaitPlanApproval(client, &LinearConfig{}, &Issue{ID: "ENG-1"}, 1)
+			if err != nil {
+				t.Fatalf("awaitPlanApproval: %v", err)
+			}
+			if approved != tt.wantApproved {
+				t.Errorf("approved = %v, want %v", approved, tt.wantApproved)
+			}
+			if client.polls != tt.wantPolls {
+				t.Errorf("polled %d times, want %d", client.polls, tt.wantPolls)
+			}
+			wantWait := time.Duration(tt.wantPolls-1) * PlanApprovalPollInterval
+			if got := fake.elapsed(); got != wantWait {
+				t.Errorf("waited %v, want %v", got, wantWait)
+			}
+		})
+	}
+}
+
+func TestIterationCooldownWaitsOnClock(t *testing.T) {
+	fake := useFakeClock(t)
+	config := defaultRalphConfig()
+	config.IterationCooldownSeconds = 90
+	withRalphConfig(t, config)
+
+	if err := iterationCooldown(2); err != nil {
+		t.Fatalf("iterationCooldown: %v", err)
+	}
+	if got := fake.elapsed(); got != 90*time.Second {
+		t.Errorf("cooldown waited %v, want 90s", got)
+	}
+}
+
+func TestExecuteStepWithRetryBacksOffOnClock(t *testing.T) {
+	fake := useFakeClock(t)
+	inTempDir(t)
+	config := defaultRalphConfig()
+	config.AgentCommand = `sh -c "echo 'context deadline exceeded' >&2; exit 1"`
+	withRalphConfig(t, config)
+	previous := cliOptions
+	cliOptions.Quiet = true
+	t.Cleanup(func() { cliOptions = previous })
+
+	_, err := executeStepWithRetry(2, "Implementation", 60, 3, "system", "prompt")
+	var agentErr *agentError
+	if !errors.As(err, &agentErr) {
+		t.Fatalf("executeStepWithRetry: got %v, want an agent error after the retries", err)
+	}
+	want := []time.Duration{StepRetryDelay, 2 * StepRetryDelay}
+	if !reflect.DeepEqual(fake.waits, want) {
+		t.Errorf("waited %v between attempts, want %v", fake.waits, want)
+	}
+}
+
+func TestEmptyPollerSleepsOnClock(t *testing.T) {
+	tests := []struct {
+		name      string
+		exitAfter int
+		polls     int
+		wantExit  bool
+	}{
+		{"on_empty = wait", 0, 5, false},
+		{"on_empty = exit-after 3", 3, 3, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := useFakeClock(t)
+			poller := &emptyPoller{exitAfter: tt.exitAfter}
+			exited := false
+			for i := 0; i < tt.polls && !exited; i++ {
+				exited = poller.idle("No Todo tickets found")
+			}
+			if exited != tt.wantExit {
+				t.Errorf("exited = %v, want %v", exited, tt.wantExit)
+			}
+			sleeps := tt.polls
+			if tt.wantExit {
+				sleeps-- // The poll that ends the session doesn't sleep
+			}
+			if got, want := fake.elapsed(), time.Duration(sleeps)*ManagerPollInterval; got != want {
+				t.Errorf("slept %v, want %v", got, want)
+			}
+		})
+	}
+}
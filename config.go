@@ -1,6 +1,16 @@
 package main
 
-import "os"
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+)
 
 // Version is the application version
 const Version = "0.4.2"
@@ -13,8 +23,11 @@ const (
 	TimeoutGuardrail       = 600  // 10 minutes for guardrail verification
 	TimeoutSelfImprovement = 1800 // 30 minutes for self-improvement analysis
 	TimeoutCommit          = 300  // 5 minutes for commit
-	TimeoutPRDCreation     = 1800 // 30 minutes for PRD creation
+	TimeoutPRDCreation     = 1800 // 30 minutes for PRD creation (default prd_timeout)
+	TimeoutGuardrailsCreation = 1800 // 30 minutes for GUARDRAILS.md generation (default guardrails_timeout)
 	TimeoutPRDSimplification = 900 // 15 minutes for PRD simplification pass
+	TimeoutTestCommand     = 1800 // 30 minutes for the test_command gate
+	TimeoutProgressSummary = 600  // 10 minutes for condensing PROGRESS.md (progress_max_bytes)
 )
 
 const (
@@ -24,18 +37,538 @@ const (
 	StateFile                = ".ralph/ralph-state.txt"
 	ManagerStateFile  = ".ralph/manager-state.txt"
 	LinearAPIEndpoint = "https://api.linear.app/graphql"
+	BitbucketAPIEndpoint = "https://api.bitbucket.org/2.0"
+)
+
+// Exit codes, the same for every command and manager mode, for scripts and CI
+const (
+	ExitComplete = 0 // Success (PRD completed, command finished)
+	ExitBlocked  = 1 // Claude or a guardrail reported a blocker
+	ExitLimit    = 2 // Iteration limit reached before the PRD was complete
+	ExitError    = 3 // Any other runtime error (git, tracker API, file system, ...)
+	ExitConfig   = 4 // Invalid arguments, configuration, or missing/unusable PRD
+	ExitAgent    = 5 // The agent (Claude CLI or agent_command) failed or timed out
+)
+
+// Claude CLI versions Ralph's flags and output handling are known to work with: >= ClaudeVersionMin, < ClaudeVersionBelow.
+// Outside this range Ralph warns at startup (skip_claude_version_check silences it).
+const (
+	ClaudeVersionMin   = "1.0.0"
+	ClaudeVersionBelow = "3.0.0"
+)
+
+// ManagerPollInterval is how long manager mode waits before checking Linear again when no ticket is available
+const ManagerPollInterval = 1 * time.Minute
+
+// Heartbeat settings for runClaude: after ClaudeHeartbeatInterval without output, print a "still working" line
+const (
+	ClaudeHeartbeatInterval = 60 * time.Second
+	ClaudeHeartbeatCheck    = 10 * time.Second
+)
+
+// StepRetryDelay is the wait before an agent step's first retry; each later retry waits twice as long as the one before
+const StepRetryDelay = 10 * time.Second
+
+// Git checkout retry settings used when setting up ticket branches
+const (
+	GitRetryAttempts = 3
+	GitRetryDelay    = 2 * time.Second
 )
 
 // GuardrailsFile is the project-root file that defines guardrails (optional). When present, Ralph verifies implementations against it.
+// guardrails_file overrides the path.
 const GuardrailsFile = "GUARDRAILS.md"
 
-// Required files
-var RequiredFiles = []string{
-	".ralph/PRD.md",
+// GuardrailsDir holds additional guardrail files: *.md directly in it always apply, *.md in a subdirectory
+// apply when the subdirectory is listed in guardrail_scopes (e.g. .ralph/guardrails/services/api/)
+const GuardrailsDir = ".ralph/guardrails"
+
+// Plan files: the current plan and the archive directory used by --keep-plans
+const (
+	PlanFile       = ".ralph/PLAN.md"
+	PlanArchiveDir = ".ralph/plans"
+)
+
+// Progress files: learnings maintained by the cleanup step, and the optional append-only history (progress_history)
+const (
+	ProgressFile        = ".ralph/PROGRESS.md"
+	ProgressHistoryFile = ".ralph/progress-history.md"
+)
+
+// IterationLogFile records the commits each iteration made (run, iteration, commit, ticket), for --show
+const IterationLogFile = ".ralph/iterations.log"
+
+// RawStreamIndexFile lists the files written by --raw-stream-file, so --clean --all can find them
+const RawStreamIndexFile = ".ralph/raw-streams.txt"
+
+// ProgressShrinkThreshold is the fraction PROGRESS.md may shrink during cleanup before Ralph warns that learnings were lost
+const ProgressShrinkThreshold = 0.2
+
+// ClaudeMDFile is the project-root agent instructions file refactored by the CLAUDE.md refactor step
+const ClaudeMDFile = "CLAUDE.md"
+
+// PRTemplateFile is the optional pull request body template used in manager mode
+const PRTemplateFile = ".ralph/pr_template.md"
+
+// RalphConfigFile is the optional loop configuration file (TOML)
+const RalphConfigFile = ".ralph/config.toml"
+
+// RalphConfig holds optional loop settings loaded from .ralph/config.toml
+type RalphConfig struct {
+	SimplifyPasses     int  `toml:"simplify_passes"`      // Number of PRD simplification passes (default 1)
+	SimplifyBeforeLoop bool `toml:"simplify_before_loop"` // Run simplification once before a fresh loop starts
+	ForceRefactor      bool `toml:"force_refactor"`       // Run the CLAUDE.md refactor even when CLAUDE.md does not exist
+	KeepPlans          bool `toml:"keep_plans"`           // Archive each PLAN.md to .ralph/plans/ before cleanup
+	CommitPerStep      bool `toml:"commit_per_step"`      // wip commit after planning and implementation (debugging aid)
+	StrictPRD          bool `toml:"strict_prd"`           // Refuse to run when the PRD still contains template placeholders
+
+	PreRunHook        string `toml:"pre_run_hook"`        // Shell command run before the loop; non-zero exit aborts the run
+	PostIterationHook string `toml:"post_iteration_hook"` // Shell command run after each iteration; failures only warn
+
+	ContextFiles []string `toml:"context_files"` // Extra files referenced (as @path) in every step prompt
+	IgnorePaths  []string `toml:"ignore_paths"`  // Globs excluded from self-improvement/refactor and from gathered context
+
+	TestCommand     string `toml:"test_command"`      // Command run after implementation; failures are fed back to Claude
+	TestFixAttempts int    `toml:"test_fix_attempts"` // Fix attempts before the iteration is marked blocked (default 2)
+	DoneCommand     string `toml:"done_command"`      // Final acceptance command; the PRD only counts as complete when it passes
+
+	ProgressHistory bool `toml:"progress_history"` // Append each iteration's new PROGRESS.md learnings to .ralph/progress-history.md
+
+	FailOnEmptyCommit bool `toml:"fail_on_empty_commit"` // Stop when consecutive passes produce no commit and leave a clean tree
+	MaxEmptyCommits   int  `toml:"max_empty_commits"`    // Consecutive empty passes allowed before stopping (default 3)
+
+	// Claude CLI permissions; when either is set, --dangerously-skip-permissions is no longer passed
+	AllowedTools   []string `toml:"allowed_tools"`   // Tools Claude may use without asking, e.g. "Edit", "Bash(go test:*)"
+	PermissionMode string   `toml:"permission_mode"` // Claude CLI --permission-mode (e.g. "acceptEdits")
+
+	AgentCommand string `toml:"agent_command"` // Command template for a non-Claude agent ({{prompt}}, {{system_prompt}}); default is the Claude CLI
+
+	Retries map[string]int `toml:"retries"` // Per-step attempt counts keyed by step name (see StepNames); default MaxRetries
+
+	PRDTimeout        int `toml:"prd_timeout"`        // Seconds allowed for PRD creation (--init, manager mode)
+	GuardrailsTimeout int `toml:"guardrails_timeout"` // Seconds allowed for GUARDRAILS.md generation (--init-guardrails)
+
+	MaxDescriptionChars int `toml:"max_description_chars"` // Longest inline --init description; longer specs go in a file (default 20000)
+
+	GuardrailMode string `toml:"guardrail_mode"` // When guardrail verification runs: per-iteration (default), final, or both
+
+	GuardrailsFile  string   `toml:"guardrails_file"`  // Guardrails file path (default GUARDRAILS.md in the project root)
+	GuardrailScopes []string `toml:"guardrail_scopes"` // Subdirectories of .ralph/guardrails/ whose rules also apply (e.g. services/api)
+
+	RecentCommits int `toml:"recent_commits"` // Include the last N commit messages in the planning prompt ({{recent_commits}}); 0 = off
+
+	SkipClaudeVersionCheck bool `toml:"skip_claude_version_check"` // Don't warn when the Claude CLI is outside the known-good version range
+
+	ManagerScopeLock bool `toml:"manager_scope_lock"` // In manager mode, skip self-improvement so a ticket's PRD never grows beyond what was planned
+
+	ClearStateOnLimit bool `toml:"clear_state_on_limit"` // Remove the state file when the iteration limit is hit (default: keep it for --continue)
+
+	ProgressMaxBytes int `toml:"progress_max_bytes"` // Condense PROGRESS.md with a summarization pass between iterations once it exceeds this size; 0 = off
+
+	IterationCooldownSeconds int `toml:"iteration_cooldown_seconds"` // Pause between iterations to stay under API rate limits; 0 = off
+
+	PersistSession bool `toml:"persist_session"` // Continue one Claude session across the steps of an iteration instead of cold-starting each step
+
+	PromptBudgetBytes int `toml:"prompt_budget_bytes"` // Refuse to run a step whose prompt plus @-referenced files exceed this many bytes; 0 = only warn above DefaultPromptBudgetBytes
+
+	CommitTrailers map[string]string `toml:"commit_trailers"` // Trailers (key → value template) appended to each iteration commit; default none
+}
+
+// Guardrail verification modes (guardrail_mode)
+const (
+	GuardrailModePerIteration = "per-iteration" // After every implementation step
+	GuardrailModeFinal        = "final"         // Once over the whole run's changes, when the PRD is complete
+	GuardrailModeBoth         = "both"
+)
+
+// guardrailPerIteration reports whether the post-implementation guardrail check runs every iteration
+func guardrailPerIteration() bool {
+	return ralphConfig.GuardrailMode != GuardrailModeFinal
+}
+
+// guardrailFinalSweep reports whether a final guardrail sweep runs when the PRD is complete
+func guardrailFinalSweep() bool {
+	return ralphConfig.GuardrailMode == GuardrailModeFinal || ralphConfig.GuardrailMode == GuardrailModeBoth
+}
+
+// StepNames are the step keys accepted in per-step config tables such as [retries]
+var StepNames = []string{
+	"planning", "plan_guardrail", "implementation", "guardrail",
+	"cleanup", "refactor", "self_improvement", "commit",
+}
+
+// PermissionModes are the Claude CLI --permission-mode values accepted in permission_mode
+var PermissionModes = []string{"default", "acceptEdits", "plan", "bypassPermissions"}
+
+// isPermissionMode reports whether mode is a valid permission_mode value
+func isPermissionMode(mode string) bool {
+	for _, m := range PermissionModes {
+		if m == mode {
+			return true
+		}
+	}
+	return false
+}
+
+// stepRetries returns the number of attempts for a step, from [retries] in config or MaxRetries
+func stepRetries(step string) int {
+	if n, ok := ralphConfig.Retries[step]; ok {
+		return n
+	}
+	return MaxRetries
+}
+
+// ralphConfig is the active loop configuration; defaults apply until loadRalphConfig is called
+var ralphConfig = defaultRalphConfig()
+
+// defaultRalphConfig returns the configuration used when .ralph/config.toml is absent
+func defaultRalphConfig() *RalphConfig {
+	return &RalphConfig{
+		SimplifyPasses:    1,
+		MaxEmptyCommits:   3,
+		TestFixAttempts:   2,
+		PRDTimeout:        TimeoutPRDCreation,
+		GuardrailsTimeout: TimeoutGuardrailsCreation,
+
+		MaxDescriptionChars: DefaultMaxDescriptionChars,
+		GuardrailMode:       GuardrailModePerIteration,
+	}
+}
+
+// loadRalphConfig loads .ralph/config.toml into ralphConfig. A missing file keeps the defaults.
+func loadRalphConfig() error {
+	data, err := os.ReadFile(RalphConfigFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to read %s: %v", RalphConfigFile, err)
+	}
+
+	config := defaultRalphConfig()
+	if err := toml.Unmarshal(data, config); err != nil {
+		return fmt.Errorf("failed to parse %s: %v", RalphConfigFile, err)
+	}
+
+	if config.SimplifyPasses < 1 {
+		return fmt.Errorf("simplify_passes must be >= 1 in %s", RalphConfigFile)
+	}
+	if config.TestFixAttempts < 0 {
+		return fmt.Errorf("test_fix_attempts must be >= 0 in %s", RalphConfigFile)
+	}
+	if config.MaxEmptyCommits < 1 {
+		return fmt.Errorf("max_empty_commits must be >= 1 in %s", RalphConfigFile)
+	}
+	if config.PRDTimeout < 1 {
+		return fmt.Errorf("prd_timeout must be >= 1 (seconds) in %s", RalphConfigFile)
+	}
+	if config.GuardrailsTimeout < 1 {
+		return fmt.Errorf("guardrails_timeout must be >= 1 (seconds) in %s", RalphConfigFile)
+	}
+	if config.MaxDescriptionChars < 1 {
+		return fmt.Errorf("max_description_chars must be >= 1 in %s", RalphConfigFile)
+	}
+	if config.RecentCommits < 0 {
+		return fmt.Errorf("recent_commits must be >= 0 in %s", RalphConfigFile)
+	}
+	if config.ProgressMaxBytes < 0 {
+		return fmt.Errorf("progress_max_bytes must be >= 0 in %s", RalphConfigFile)
+	}
+	if config.IterationCooldownSeconds < 0 {
+		return fmt.Errorf("iteration_cooldown_seconds must be >= 0 in %s", RalphConfigFile)
+	}
+	if config.PromptBudgetBytes < 0 {
+		return fmt.Errorf("prompt_budget_bytes must be >= 0 in %s", RalphConfigFile)
+	}
+	if err := validateCommitTrailers(config.CommitTrailers); err != nil {
+		return err
+	}
+	for step, n := range config.Retries {
+		if !isStepName(step) {
+			return fmt.Errorf("unknown step %q in [retries] in %s (valid: %s)", step, RalphConfigFile, strings.Join(StepNames, ", "))
+		}
+		if n < 1 {
+			return fmt.Errorf("retries for %s must be >= 1 in %s", step, RalphConfigFile)
+		}
+	}
+
+	switch config.GuardrailMode {
+	case GuardrailModePerIteration, GuardrailModeFinal, GuardrailModeBoth:
+	default:
+		return fmt.Errorf("invalid guardrail_mode %q in %s (valid: %s, %s, %s)", config.GuardrailMode, RalphConfigFile, GuardrailModePerIteration, GuardrailModeFinal, GuardrailModeBoth)
+	}
+	if config.PermissionMode != "" && !isPermissionMode(config.PermissionMode) {
+		return fmt.Errorf("invalid permission_mode %q in %s (valid: %s)", config.PermissionMode, RalphConfigFile, strings.Join(PermissionModes, ", "))
+	}
+	if config.AgentCommand != "" && !strings.Contains(config.AgentCommand, AgentPromptPlaceholder) {
+		return fmt.Errorf("agent_command in %s must contain %s", RalphConfigFile, AgentPromptPlaceholder)
+	}
+	if _, err := splitCommandTemplate(config.AgentCommand); err != nil {
+		return err
+	}
+
+	for _, pattern := range config.IgnorePaths {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid ignore_paths pattern %q in %s: %v", pattern, RalphConfigFile, err)
+		}
+	}
+	for _, file := range config.ContextFiles {
+		if _, err := os.Stat(file); err != nil {
+			statusf("⚠️  Warning: context file %s listed in %s does not exist, skipping\n", file, RalphConfigFile)
+		}
+	}
+	for _, scope := range config.GuardrailScopes {
+		if info, err := os.Stat(filepath.Join(GuardrailsDir, scope)); err != nil || !info.IsDir() {
+			statusf("⚠️  Warning: guardrail scope %s listed in %s has no directory in %s, skipping\n", scope, RalphConfigFile, GuardrailsDir)
+		}
+	}
+
+	ralphConfig = config
+	return nil
+}
+
+// contextFileRefs returns @path references for the configured context_files that exist and aren't ignored
+func contextFileRefs() []string {
+	var refs []string
+	for _, file := range ralphConfig.ContextFiles {
+		if _, err := os.Stat(file); err == nil && !isIgnoredPath(file) {
+			refs = append(refs, "@"+file)
+		}
+	}
+	return refs
+}
+
+// isIgnoredPath reports whether a path matches ignore_paths (as a glob against the full path or any
+// leading directory) or is ignored by git (.gitignore)
+func isIgnoredPath(path string) bool {
+	path = filepath.ToSlash(filepath.Clean(path))
+	for _, pattern := range ralphConfig.IgnorePaths {
+		pattern = strings.TrimSuffix(filepath.ToSlash(pattern), "/")
+		if ok, _ := filepath.Match(pattern, path); ok {
+			return true
+		}
+		// Patterns without a slash also match the file name anywhere (like .gitignore)
+		if !strings.Contains(pattern, "/") {
+			if ok, _ := filepath.Match(pattern, filepath.Base(path)); ok {
+				return true
+			}
+		}
+		// A pattern matching a directory covers everything below it
+		parts := strings.Split(path, "/")
+		for i := 1; i < len(parts); i++ {
+			if ok, _ := filepath.Match(pattern, strings.Join(parts[:i], "/")); ok {
+				return true
+			}
+		}
+	}
+	if ralphIgnored(path) {
+		return true
+	}
+	return gitRun("check-ignore", "-q", path) == nil
+}
+
+// RalphIgnoreFile lists paths (gitignore syntax) that are never @-referenced in any prompt
+const RalphIgnoreFile = ".ralph/.ralphignore"
+
+// ralphIgnored reports whether .ralph/.ralphignore excludes path. Matching is done by git so the full
+// gitignore syntax (negation, anchors, **) is supported; --no-index makes it apply to tracked files too.
+func ralphIgnored(path string) bool {
+	if _, err := os.Stat(RalphIgnoreFile); err != nil {
+		return false
+	}
+	ignoreFile, err := filepath.Abs(RalphIgnoreFile)
+	if err != nil {
+		return false
+	}
+	return gitRun("-c", "core.excludesFile="+ignoreFile, "check-ignore", "-q", "--no-index", path) == nil
+}
+
+// promptRefPattern matches @path references at the start of a prompt or after whitespace (not e-mail addresses)
+var promptRefPattern = regexp.MustCompile(`(^|\s)@([^\s@]+)`)
+
+// stripRalphIgnoredRefs removes @path references excluded by .ralph/.ralphignore from a prompt
+func stripRalphIgnoredRefs(prompt string) string {
+	if _, err := os.Stat(RalphIgnoreFile); err != nil {
+		return prompt
+	}
+	return promptRefPattern.ReplaceAllStringFunc(prompt, func(match string) string {
+		groups := promptRefPattern.FindStringSubmatch(match)
+		if ralphIgnored(groups[2]) {
+			return groups[1]
+		}
+		return match
+	})
+}
+
+// reportedMissingRefs holds the missing @path references already reported, so each is mentioned once per run
+var reportedMissingRefs = make(map[string]bool)
+
+// dereferenceMissingRefs turns @path references to files that don't exist (e.g. @CLAUDE.md or @GUARDRAILS.md on a
+// minimal repo) into plain paths, so Claude gets no dangling references while the prompt's wording stays intact.
+// Only file-like references (containing "." or "/") are considered; trailing punctuation is not part of the path.
+func dereferenceMissingRefs(prompt string) string {
+	return promptRefPattern.ReplaceAllStringFunc(prompt, func(match string) string {
+		groups := promptRefPattern.FindStringSubmatch(match)
+		path := strings.TrimRight(groups[2], ".,;:)!?'\"`")
+		if path == "" || !strings.ContainsAny(path, "./") {
+			return match
+		}
+		if _, err := os.Stat(path); err == nil {
+			return match
+		}
+		if !reportedMissingRefs[path] {
+			reportedMissingRefs[path] = true
+			statusf("ℹ️  %s does not exist; prompts mention it without an @ reference\n", path)
+		}
+		return groups[1] + groups[2]
+	})
+}
+
+// DefaultPromptBudgetBytes is the size above which a prompt is warned about when prompt_budget_bytes is unset:
+// at roughly 4 bytes per token, about 150k tokens, which leaves room in a 200k-token context window for the
+// system prompt, tool calls and the reply
+const DefaultPromptBudgetBytes = 600000
+
+// promptBudgetWarned makes the default (warn-only) budget check warn once per run
+var promptBudgetWarned bool
+
+// checkPromptBudget estimates a step's context as the bytes of its prompts plus every existing @-referenced file
+// and reports the largest contributors when the estimate exceeds the budget. It can't count tokens, but it turns
+// an opaque context-limit error from the API into something that says which file to trim. Without
+// prompt_budget_bytes it only warns above DefaultPromptBudgetBytes; with it, it fails with a configError
+// (the fix is in the project's files or config, not the agent).
+func checkPromptBudget(systemPrompt, prompt string) error {
+	budget := ralphConfig.PromptBudgetBytes
+	enforced := budget > 0
+	if !enforced {
+		if promptBudgetWarned {
+			return nil
+		}
+		budget = DefaultPromptBudgetBytes
+	}
+
+	type contributor struct {
+		name string
+		size int64
+	}
+	contributors := []contributor{{"the prompt itself", int64(len(systemPrompt) + len(prompt))}}
+	total := contributors[0].size
+	seen := make(map[string]bool)
+	for _, groups := range promptRefPattern.FindAllStringSubmatch(prompt, -1) {
+		path := strings.TrimRight(groups[2], ".,;:)!?'\"`")
+		if path == "" || seen[path] {
+			continue
+		}
+		seen[path] = true
+		info, err := os.Stat(path)
+		if err != nil || info.IsDir() {
+			continue
+		}
+		contributors = append(contributors, contributor{path, info.Size()})
+		total += info.Size()
+	}
+	if total <= int64(budget) {
+		return nil
+	}
+
+	sort.Slice(contributors, func(i, j int) bool { return contributors[i].size > contributors[j].size })
+	if len(contributors) > 5 {
+		contributors = contributors[:5]
+	}
+	var largest []string
+	for _, c := range contributors {
+		largest = append(largest, fmt.Sprintf("%s (%d KB)", c.name, c.size/1024))
+	}
+	if !enforced {
+		promptBudgetWarned = true
+		statusf("⚠️  Warning: the prompt and its referenced files come to about %d KB and may exceed the model's context window. Largest: %s. "+
+			"Trim or split the large files, or set prompt_budget_bytes in %s to stop before such a step instead\n", total/1024, strings.Join(largest, ", "), RalphConfigFile)
+		return nil
+	}
+	return &configError{fmt.Errorf("the prompt and its referenced files come to about %d KB, over prompt_budget_bytes (%d), "+
+		"and would likely exceed the model's context window. Largest: %s. Trim or split the large files "+
+		"(e.g. set progress_max_bytes to condense %s, move finished PRD tasks out, or drop context_files), "+
+		"or raise prompt_budget_bytes in %s", total/1024, budget, strings.Join(largest, ", "), ProgressFile, RalphConfigFile)}
+}
+
+// Run modes, used to decide which files must exist
+const (
+	ModeLoop        = "loop"         // The development loop (ralph <iterations>, --continue, manager mode)
+	ModeSelfImprove = "self-improve" // --only self-improve, which appends the tasks it finds to the PRD
+	ModeOnly        = "only"         // The other ad hoc workflows (--only guardrail, --only refactor)
+	ModeReview      = "review"       // --review-only
+)
+
+// requiredFiles returns the files a run mode needs: the development loop works through a PRD and
+// --only self-improve adds tasks to it, while ad hoc analysis (--only guardrail/refactor, --review-only)
+// can run against any repository
+func requiredFiles(mode string) []string {
+	switch mode {
+	case ModeLoop, ModeSelfImprove:
+		return []string{SamplePRDFile}
+	}
+	return nil
+}
+
+// PRDDir holds additional PRD markdown files for projects that split requirements across documents
+const PRDDir = ".ralph/prd"
+
+// requiredFileExists reports whether a required file is present. .ralph/PRD.md may be replaced by files in .ralph/prd/.
+func requiredFileExists(filename string) bool {
+	if _, err := os.Stat(filename); err == nil {
+		return true
+	}
+	return filename == SamplePRDFile && len(prdDirFiles()) > 0
+}
+
+// guardrailsFilePath returns the guardrails file path: guardrails_file, or GUARDRAILS.md in the project root
+func guardrailsFilePath() string {
+	if ralphConfig.GuardrailsFile != "" {
+		return ralphConfig.GuardrailsFile
+	}
+	return GuardrailsFile
+}
+
+// guardrailFiles returns the active guardrail files: the guardrails file (if present), the *.md files in
+// .ralph/guardrails/, then those in each guardrail_scopes subdirectory
+func guardrailFiles() []string {
+	var files []string
+	if _, err := os.Stat(guardrailsFilePath()); err == nil {
+		files = append(files, guardrailsFilePath())
+	}
+	for _, dir := range append([]string{""}, ralphConfig.GuardrailScopes...) {
+		matches, err := filepath.Glob(filepath.Join(GuardrailsDir, dir, "*.md"))
+		if err != nil {
+			continue
+		}
+		sort.Strings(matches)
+		files = append(files, matches...)
+	}
+	return files
 }
 
-// guardrailsExists returns true if GUARDRAILS.md exists in the project root.
+// guardrailsExists returns true if any guardrail file is active (the guardrails file or .ralph/guardrails/)
 func guardrailsExists() bool {
-	_, err := os.Stat(GuardrailsFile)
+	return len(guardrailFiles()) > 0
+}
+
+// guardrailsLocation describes where guardrails are read from, for messages when none are found
+func guardrailsLocation() string {
+	return fmt.Sprintf("%s (or %s/*.md)", guardrailsFilePath(), GuardrailsDir)
+}
+
+// isStepName reports whether name is one of StepNames
+func isStepName(name string) bool {
+	for _, step := range StepNames {
+		if step == name {
+			return true
+		}
+	}
+	return false
+}
+
+// claudeMDExists returns true if CLAUDE.md exists in the project root.
+func claudeMDExists() bool {
+	_, err := os.Stat(ClaudeMDFile)
 	return err == nil
 }
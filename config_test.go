@@ -0,0 +1,33 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCheckPromptBudget(t *testing.T) {
+	inTempDir(t)
+	writeTestFile(t, "big.md", strings.Repeat("x", DefaultPromptBudgetBytes+1))
+	prompt := "@big.md Do the task."
+
+	config := defaultRalphConfig()
+	withRalphConfig(t, config)
+	promptBudgetWarned = false
+	t.Cleanup(func() { promptBudgetWarned = false })
+	if err := checkPromptBudget("", prompt); err != nil {
+		t.Errorf("unset prompt_budget_bytes: got %v, want only a warning", err)
+	}
+	if !promptBudgetWarned {
+		t.Error("unset prompt_budget_bytes: no warning for a prompt over the default budget")
+	}
+
+	config.PromptBudgetBytes = 1000
+	var configErr *configError
+	if err := checkPromptBudget("", prompt); !errors.As(err, &configErr) || !strings.Contains(err.Error(), "big.md") {
+		t.Errorf("prompt_budget_bytes = 1000: got %v, want a config error naming big.md", err)
+	}
+	if err := checkPromptBudget("", "Do the task."); err != nil {
+		t.Errorf("prompt within the budget: %v", err)
+	}
+}
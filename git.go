@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// gitRun runs a git command, discarding its output unless --verbose-git is set
+func gitRun(args ...string) error {
+	_, err := gitCombinedOutput(args...)
+	return err
+}
+
+// gitOutput runs a git command and returns its stdout (like exec.Cmd.Output)
+func gitOutput(args ...string) ([]byte, error) {
+	cmd := exec.Command("git", args...)
+	output, err := cmd.Output()
+	if cliOptions.VerboseGit {
+		stderr := ""
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			stderr = string(exitErr.Stderr)
+		}
+		logGitCommand(args, string(output)+stderr, err)
+	}
+	return output, err
+}
+
+// gitCombinedOutput runs a git command and returns its combined stdout and stderr
+func gitCombinedOutput(args ...string) ([]byte, error) {
+	cmd := exec.Command("git", args...)
+	output, err := cmd.CombinedOutput()
+	if cliOptions.VerboseGit {
+		logGitCommand(args, string(output), err)
+	}
+	return output, err
+}
+
+// splitNUL splits NUL-separated git output (-z) into its non-empty entries
+func splitNUL(output []byte) []string {
+	var entries []string
+	for _, entry := range strings.Split(string(output), "\x00") {
+		if entry != "" {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// markdownPath formats a file path as inline code for ticket comments. Invalid UTF-8 is replaced,
+// and paths containing backticks get a longer fence so they can't break the markdown.
+func markdownPath(path string) string {
+	path = strings.ToValidUTF8(path, "\uFFFD")
+	if !strings.Contains(path, "`") {
+		return "`" + path + "`"
+	}
+	return "`` " + path + " ``"
+}
+
+// logGitCommand echoes a git command, its output and its exit status (--verbose-git)
+func logGitCommand(args []string, output string, err error) {
+	statusf("🔧 git %s\n", strings.Join(args, " "))
+	for _, line := range strings.Split(strings.TrimRight(output, "\n"), "\n") {
+		if line != "" {
+			fmt.Printf("   │ %s\n", line)
+		}
+	}
+	if err != nil {
+		fmt.Printf("   └ %v\n", err)
+	}
+}
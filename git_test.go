@@ -0,0 +1,82 @@
+package main
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestChangedFileListsWithBinaryUnicodeAndRename(t *testing.T) {
+	initGitRepo(t)
+	runGit(t, "config", "diff.renames", "true")
+	writeTestFile(t, "old name.txt", strings.Repeat("a line that survives the rename\n", 20))
+	runGit(t, "add", "-A")
+	runGit(t, "commit", "-q", "-m", "Add a file to rename")
+
+	runGit(t, "mv", "old name.txt", "new name.txt")
+	writeTestFile(t, "naïve file.bin", "\x00\x01\x02\xff\xfe binary\x00")
+	runGit(t, "add", "-A")
+
+	want := []string{"naïve file.bin", "new name.txt"}
+	uncommitted := getUncommittedFiles()
+	sort.Strings(uncommitted)
+	if !reflect.DeepEqual(uncommitted, want) {
+		t.Errorf("getUncommittedFiles() = %q, want %q", uncommitted, want)
+	}
+
+	runGit(t, "commit", "-q", "-m", "Rename and add a binary")
+	changed := getChangedFiles()
+	sort.Strings(changed)
+	if !reflect.DeepEqual(changed, want) {
+		t.Errorf("getChangedFiles() = %q, want %q", changed, want)
+	}
+
+	summary, err := getDiffstatSummary("HEAD~1", "HEAD")
+	if err != nil {
+		t.Fatalf("getDiffstatSummary: %v", err)
+	}
+	for _, line := range []string{
+		"**Changes:** 2 file(s) changed, +0 / -0",
+		"- `naïve file.bin` (binary)",
+		"- `new name.txt` (+0 / -0)",
+	} {
+		if !strings.Contains(summary, line) {
+			t.Errorf("diffstat summary is missing %q:\n%s", line, summary)
+		}
+	}
+}
+
+func TestMarkdownPath(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"naïve file.bin", "`naïve file.bin`"},
+		{"docs/a`b.md", "`` docs/a`b.md ``"},
+		{"bad\xffname", "`bad\uFFFDname`"},
+	}
+	for _, tt := range tests {
+		if got := markdownPath(tt.path); got != tt.want {
+			t.Errorf("markdownPath(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestDiffstatSummaryOrdersByLinesChanged(t *testing.T) {
+	initGitRepo(t)
+	writeTestFile(t, "a.txt", "one\n")
+	writeTestFile(t, "b.txt", "one\ntwo\nthree\n")
+	writeTestFile(t, "c.txt", "one\n")
+	runGit(t, "add", "-A")
+	runGit(t, "commit", "-q", "-m", "Add files")
+
+	summary, err := getDiffstatSummary("HEAD~1", "HEAD")
+	if err != nil {
+		t.Fatalf("getDiffstatSummary: %v", err)
+	}
+	want := "**Changes:** 3 file(s) changed, +5 / -0\n- `b.txt` (+3 / -0)\n- `a.txt` (+1 / -0)\n- `c.txt` (+1 / -0)"
+	if summary != want {
+		t.Errorf("diffstat summary:\n%s\nwant (largest first, ties in path order):\n%s", summary, want)
+	}
+}
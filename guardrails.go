@@ -3,6 +3,7 @@ package main
 import (
 	"fmt"
 	"os"
+	"regexp"
 	"strings"
 )
 
@@ -38,8 +39,9 @@ OUTPUT REQUIREMENTS:
 
 // createGuardrailsWithClaude analyzes the project and generates GUARDRAILS.md using Claude.
 func createGuardrailsWithClaude() error {
-	if _, err := os.Stat(GuardrailsFile); err == nil {
-		fmt.Printf("%s already exists\n", GuardrailsFile)
+	path := guardrailsFilePath()
+	if _, err := os.Stat(path); err == nil {
+		fmt.Printf("%s already exists\n", path)
 		return nil
 	}
 
@@ -54,7 +56,7 @@ func createGuardrailsWithClaude() error {
 	fmt.Println("Analyzing project and generating GUARDRAILS.md...")
 	fmt.Println()
 
-	result, err := runClaude(TimeoutPRDCreation, GuardrailsCreationSystemPrompt, prompt)
+	result, err := runClaude(ralphConfig.GuardrailsTimeout, GuardrailsCreationSystemPrompt, prompt)
 	if err != nil {
 		return fmt.Errorf("guardrails creation failed: %w", err)
 	}
@@ -67,12 +69,12 @@ func createGuardrailsWithClaude() error {
 		return fmt.Errorf("could not extract GUARDRAILS.md from Claude output (length: %d)", len(result.Output))
 	}
 
-	if err := writeFileContent(GuardrailsFile, content); err != nil {
-		return fmt.Errorf("failed to write %s: %w", GuardrailsFile, err)
+	if err := writeFileContent(path, content); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
 	}
 
-	fmt.Printf("✅ Created %s\n", GuardrailsFile)
-	fmt.Println("Edit GUARDRAILS.md to refine rules. When present, Ralph verifies the plan and PRD/outcome compliance against it (before and after each implementation step).")
+	statusf("✅ Created %s\n", path)
+	fmt.Printf("Edit %s to refine rules. When present, Ralph verifies the plan and PRD/outcome compliance against it (before and after each implementation step).\n", path)
 	return nil
 }
 
@@ -84,7 +86,7 @@ func gatherProjectRefs() []string {
 	}
 	var refs []string
 	for _, name := range candidates {
-		if _, err := os.Stat(name); err == nil {
+		if _, err := os.Stat(name); err == nil && !isIgnoredPath(name) {
 			refs = append(refs, "@"+name)
 		}
 	}
@@ -147,3 +149,66 @@ func extractGuardrailsFromOutput(output string) string {
 
 	return ""
 }
+
+// GuardrailReport summarizes a guardrail verification run
+type GuardrailReport struct {
+	Compliant bool     // <promise>COMPLIANT</promise> emitted
+	Blocked   bool     // <promise>BLOCKED</promise> emitted
+	Findings  []string // What was fixed or violated, from the output's findings sections
+}
+
+// guardrailFindingPattern matches markdown list items ("- ...", "* ...", "1. ...")
+var guardrailFindingPattern = regexp.MustCompile(`^\s*(?:[-*+]|\d+[.)])\s+(.+)$`)
+
+// guardrailSectionPattern matches the heading of a findings section ("Fixed:", "## Violations", "**Findings**")
+var guardrailSectionPattern = regexp.MustCompile(`(?i)^\s*(?:#+\s*)?\**\s*(?:fixed|fixes|violations?|findings?)\b[^.!?]*$`)
+
+// guardrailTaggedFindingPattern matches a list item tagged as a finding anywhere in the output ("- FIXED: ...")
+var guardrailTaggedFindingPattern = regexp.MustCompile(`^(?i:\**\s*(?:fixed|violation)\s*:)`)
+
+// newGuardrailReport builds a report from a guardrail verification result. Only list items under a findings
+// heading (Fixed, Violations, Findings) or tagged "FIXED:"/"VIOLATION:" count, so restated rules and
+// checklists elsewhere in the output don't inflate the numbers.
+func newGuardrailReport(result *ClaudeResult) *GuardrailReport {
+	report := &GuardrailReport{
+		Compliant: result.Compliant,
+		Blocked:   result.Blocked,
+	}
+	report.Findings = guardrailFindings(result.Output)
+	return report
+}
+
+// guardrailFindings extracts the findings list items from guardrail verification output
+func guardrailFindings(output string) []string {
+	var findings []string
+	inSection := false
+	for _, line := range strings.Split(output, "\n") {
+		if matches := guardrailFindingPattern.FindStringSubmatch(line); matches != nil {
+			item := strings.TrimSpace(matches[1])
+			if inSection || guardrailTaggedFindingPattern.MatchString(item) {
+				findings = append(findings, item)
+			}
+			continue
+		}
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		// Any other line starts a findings section or ends the current one
+		inSection = guardrailSectionPattern.MatchString(line)
+	}
+	return findings
+}
+
+// String returns a one-line summary, e.g. "COMPLIANT", "COMPLIANT after 2 fix(es)", "BLOCKED (1 violation(s))"
+func (r *GuardrailReport) String() string {
+	switch {
+	case r.Blocked:
+		return fmt.Sprintf("BLOCKED (%d violation(s))", len(r.Findings))
+	case r.Compliant && len(r.Findings) > 0:
+		return fmt.Sprintf("COMPLIANT after %d fix(es)", len(r.Findings))
+	case r.Compliant:
+		return "COMPLIANT"
+	default:
+		return "UNVERIFIED (no COMPLIANT or BLOCKED marker)"
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGuardrailFindings(t *testing.T) {
+	output := `I read the guardrail rules:
+- No hardcoded secrets
+- Every task has verification criteria
+
+Checklist:
+1. Secrets scanned
+2. Criteria checked
+
+Fixed:
+- Moved the API key in config.go to an environment variable
+- Added a verification criterion to Task 2
+
+Everything else complies.
+- FIXED: removed a production mock
+<promise>COMPLIANT</promise>`
+
+	want := []string{
+		"Moved the API key in config.go to an environment variable",
+		"Added a verification criterion to Task 2",
+		"FIXED: removed a production mock",
+	}
+	report := newGuardrailReport(&ClaudeResult{Output: output, Compliant: true})
+	if !reflect.DeepEqual(report.Findings, want) {
+		t.Errorf("Findings = %q, want %q", report.Findings, want)
+	}
+	if got := report.String(); got != "COMPLIANT after 3 fix(es)" {
+		t.Errorf("String() = %q", got)
+	}
+}
+
+func TestGuardrailFindingsCleanPass(t *testing.T) {
+	output := "Rules:\n- No secrets\n- Criteria present\n\nAll rules are satisfied.\n<promise>COMPLIANT</promise>"
+	report := newGuardrailReport(&ClaudeResult{Output: output, Compliant: true})
+	if len(report.Findings) != 0 {
+		t.Errorf("Findings = %q, want none", report.Findings)
+	}
+	if got := report.String(); got != "COMPLIANT" {
+		t.Errorf("String() = %q, want COMPLIANT", got)
+	}
+}
+
+func TestGuardrailFindingsBlocked(t *testing.T) {
+	output := "## Violations\n\n- Task 3 stores passwords in plain text\n\n<promise>BLOCKED</promise>"
+	report := newGuardrailReport(&ClaudeResult{Output: output, Blocked: true})
+	if got := report.String(); got != "BLOCKED (1 violation(s))" {
+		t.Errorf("String() = %q", got)
+	}
+}
+
+func TestWithGuardrailRefsExpandsOnlyTheReferenceLine(t *testing.T) {
+	inTempDir(t)
+	withRalphConfig(t, defaultRalphConfig())
+	writeTestFile(t, GuardrailsFile, "# Guardrails\n")
+	writeTestFile(t, GuardrailsDir+"/security.md", "# Security\n")
+
+	prompt := "@.ralph/PRD.md @GUARDRAILS.md \\\n1. If @GUARDRAILS.md exists, follow it."
+	want := "@.ralph/PRD.md @GUARDRAILS.md @.ralph/guardrails/security.md \\\n1. If @GUARDRAILS.md exists, follow it."
+	if got := withGuardrailRefs(prompt); got != want {
+		t.Errorf("withGuardrailRefs() = %q, want %q", got, want)
+	}
+
+	prose := "Check the plan.\nIf @GUARDRAILS.md exists, follow it."
+	if got := withGuardrailRefs(prose); got != prose {
+		t.Errorf("withGuardrailRefs() rewrote prose: %q", got)
+	}
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+)
+
+// runHook runs a configured shell hook command, passing loop context through environment variables:
+// RALPH_HOOK (hook name), RALPH_ITERATION, RALPH_MAX_ITERATIONS and RALPH_BRANCH.
+// The hook's output is streamed to the console. Returns an error if the command exits non-zero.
+func runHook(name, command string, iteration, maxIterations int) error {
+	branch, _ := getCurrentGitBranch()
+
+	cmd := exec.Command("sh", "-c", command)
+	cmd.Env = append(os.Environ(),
+		fmt.Sprintf("RALPH_HOOK=%s", name),
+		fmt.Sprintf("RALPH_ITERATION=%d", iteration),
+		fmt.Sprintf("RALPH_MAX_ITERATIONS=%d", maxIterations),
+		fmt.Sprintf("RALPH_BRANCH=%s", branch),
+	)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	statusf("🪝 Running %s hook: %s\n", name, command)
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s hook failed: %v", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// IssueProvider is the issue tracker manager mode takes tickets from (Linear or Jira).
+// State names are Ralph's: "Todo", "In Progress" and "Done"; providers map them to their own workflow.
+type IssueProvider interface {
+	// Name returns a human-readable tracker name for log messages
+	Name() string
+	// BranchPrefix returns the tracker's branch prefix ({{prefix}} in branch_template)
+	BranchPrefix() string
+	// issueIDFromBranch extracts the ticket ID from a branch in the legacy {prefix}/{issue-id}-{slug} scheme, or returns ""
+	issueIDFromBranch(branchName string) string
+
+	fetchTodoTickets(projectID string) ([]Issue, error)
+	getIssue(issueID string) (*Issue, error)
+	updateTicketStatus(issueID, teamID, stateName string) error
+	verifyIssueState(issueID, expectedState string) (bool, error)
+	addLabel(issue *Issue, name string) error
+	// verifyUser checks that a user to mention (escalate_user) exists, so escalations reach someone
+	verifyUser(username string) error
+
+	addTicketComment(issueID, comment string, usernames []string) error
+	createTicketComment(issueID, comment string, usernames []string) (string, error)
+	getCommentBody(issueID, commentID string) (string, error)
+	updateTicketComment(issueID, commentID, body string) error
+	// listTicketComments returns a ticket's comments, oldest first (used to settle ticket claims)
+	listTicketComments(issueID string) ([]TicketComment, error)
+}
+
+// TicketComment is a comment on a ticket, with the tracker's own creation time
+type TicketComment struct {
+	ID        string
+	Body      string
+	CreatedAt time.Time
+}
+
+// Supported values for tracker in the manager config
+const (
+	TrackerLinear = "linear"
+	TrackerJira   = "jira"
+)
+
+// newIssueProvider creates the client for the configured tracker. For Linear, a project slug or name
+// is resolved to its UUID first.
+func newIssueProvider(config *LinearConfig) (IssueProvider, error) {
+	switch strings.ToLower(config.Tracker) {
+	case "", TrackerLinear:
+		client := NewLinearClient(config.Token)
+		if err := resolveLinearProject(client, config); err != nil {
+			return nil, err
+		}
+		return client, nil
+	case TrackerJira:
+		return NewJiraClient(config), nil
+	default:
+		return nil, fmt.Errorf("unknown tracker %q (must be %s or %s)", config.Tracker, TrackerLinear, TrackerJira)
+	}
+}
+
+// DefaultBranchTemplate is the ticket branch scheme when branch_template is not set (e.g. linear/ENG-123-add-login)
+const DefaultBranchTemplate = "{{prefix}}/{{identifier}}-{{slug}}"
+
+// Placeholders accepted in branch_template
+const (
+	BranchPrefixPlaceholder     = "{{prefix}}"     // Tracker prefix: linear or jira
+	BranchIdentifierPlaceholder = "{{identifier}}" // Human-readable identifier (ENG-123, PROJ-42)
+	BranchIDPlaceholder         = "{{id}}"         // Tracker ID (Linear issue UUID, Jira key)
+	BranchSlugPlaceholder       = "{{slug}}"       // Slugified ticket title
+)
+
+// branchPlaceholderPattern matches any {{...}} placeholder in a branch template
+var branchPlaceholderPattern = regexp.MustCompile(`\{\{[^}]*\}\}`)
+
+// branchTemplate returns the configured branch template, or DefaultBranchTemplate
+func branchTemplate(config *LinearConfig) string {
+	if config.BranchTemplate != "" {
+		return config.BranchTemplate
+	}
+	return DefaultBranchTemplate
+}
+
+// validateBranchTemplate returns a problem with branch_template, or "" when it is usable. The template needs
+// {{identifier}} or {{id}} so an interrupted ticket can be recovered from its branch name.
+func validateBranchTemplate(template string) string {
+	for _, placeholder := range branchPlaceholderPattern.FindAllString(template, -1) {
+		switch placeholder {
+		case BranchPrefixPlaceholder, BranchIdentifierPlaceholder, BranchIDPlaceholder, BranchSlugPlaceholder:
+		default:
+			return fmt.Sprintf("branch_template has unknown placeholder %s (valid: %s, %s, %s, %s)", placeholder, BranchPrefixPlaceholder, BranchIdentifierPlaceholder, BranchIDPlaceholder, BranchSlugPlaceholder)
+		}
+	}
+	if !strings.Contains(template, BranchIdentifierPlaceholder) && !strings.Contains(template, BranchIDPlaceholder) {
+		return fmt.Sprintf("branch_template must contain %s or %s", BranchIdentifierPlaceholder, BranchIDPlaceholder)
+	}
+	return ""
+}
+
+// ticketBranchName returns the branch for a ticket from branch_template
+func ticketBranchName(client IssueProvider, issue *Issue, config *LinearConfig) string {
+	identifier := issue.Identifier
+	if identifier == "" {
+		identifier = issue.ID
+	}
+	name := strings.NewReplacer(
+		BranchPrefixPlaceholder, client.BranchPrefix(),
+		BranchIdentifierPlaceholder, identifier,
+		BranchIDPlaceholder, issue.ID,
+		BranchSlugPlaceholder, slugify(issue.Title),
+	).Replace(branchTemplate(config))
+	return strings.TrimRight(name, "-/")
+}
+
+// branchTemplateRegexp compiles a branch template into a pattern whose first group is the ticket ID or identifier
+func branchTemplateRegexp(prefix, template string) *regexp.Regexp {
+	var pattern strings.Builder
+	pattern.WriteString("^")
+	captured := false
+	rest := template
+	for {
+		loc := branchPlaceholderPattern.FindStringIndex(rest)
+		if loc == nil {
+			pattern.WriteString(regexp.QuoteMeta(rest))
+			break
+		}
+		pattern.WriteString(regexp.QuoteMeta(rest[:loc[0]]))
+		switch placeholder := rest[loc[0]:loc[1]]; {
+		case placeholder == BranchPrefixPlaceholder:
+			pattern.WriteString(regexp.QuoteMeta(prefix))
+		case placeholder == BranchSlugPlaceholder:
+			pattern.WriteString(`[a-z0-9-]*`)
+		case !captured && (placeholder == BranchIdentifierPlaceholder || placeholder == BranchIDPlaceholder):
+			// Identifiers (ENG-123) and Jira keys, or Linear issue UUIDs
+			pattern.WriteString(`([A-Z][A-Z0-9_]*-[0-9]+|[a-f0-9]{8}-[a-f0-9]{4}-[a-f0-9]{4}-[a-f0-9]{4}-[a-f0-9]{12})`)
+			captured = true
+		default:
+			pattern.WriteString(`[A-Za-z0-9_-]+`)
+		}
+		rest = rest[loc[1]:]
+	}
+	pattern.WriteString("$")
+	return regexp.MustCompile(pattern.String())
+}
+
+// issueIDFromBranchName extracts the ticket ID or identifier from a branch created with branch_template, falling
+// back to the tracker's legacy {prefix}/{issue-id}-{slug} scheme so tickets started before an upgrade still recover
+func issueIDFromBranchName(client IssueProvider, config *LinearConfig, branchName string) string {
+	pattern := branchTemplateRegexp(client.BranchPrefix(), branchTemplate(config))
+	// ticketBranchName trims the separator left by an empty slug
+	for _, candidate := range []string{branchName, branchName + "-"} {
+		if matches := pattern.FindStringSubmatch(candidate); len(matches) >= 2 {
+			return matches[1]
+		}
+	}
+	return client.issueIDFromBranch(branchName)
+}
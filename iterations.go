@@ -0,0 +1,222 @@
+package main
+
+import (
+	"crypto/rand"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// iterationTicket, when set, tags the commits recorded in the iteration log (manager mode sets the ticket identifier)
+var iterationTicket string
+
+// currentRunID identifies the running loop in the iteration log (--show picks the latest run of an iteration)
+// and in commit trailers
+var currentRunID string
+
+// newRunID returns a run ID: the start time plus a random suffix, so runs started in the same second
+// (e.g. manager mode moving on to the next ticket) still get different IDs
+func newRunID() string {
+	started := clock.Now().Format("20060102T150405")
+	var b [4]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return fmt.Sprintf("%s-%d", started, os.Getpid())
+	}
+	return fmt.Sprintf("%s-%x", started, b)
+}
+
+// IterationCommit is one line of the iteration log: a commit made during an iteration of a run
+type IterationCommit struct {
+	Run       string
+	Iteration int
+	Commit    string
+	Ticket    string
+}
+
+// recordIterationCommits appends the commits made since base (oldest first) to the iteration log for --show.
+// Failures only warn: the log is an audit aid, not something the run depends on.
+func recordIterationCommits(run string, iteration int, base string) {
+	if base == "" {
+		return
+	}
+	output, err := gitOutput("rev-list", "--reverse", base+"..HEAD")
+	if err != nil {
+		statusf("⚠️  Warning: failed to list iteration %d commits: %v\n", iteration, err)
+		return
+	}
+	commits := strings.Fields(string(output))
+	if len(commits) == 0 {
+		return
+	}
+
+	if err := os.MkdirAll(filepath.Dir(IterationLogFile), 0755); err != nil {
+		statusf("⚠️  Warning: failed to create %s: %v\n", filepath.Dir(IterationLogFile), err)
+		return
+	}
+	file, err := os.OpenFile(IterationLogFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		statusf("⚠️  Warning: failed to open %s: %v\n", IterationLogFile, err)
+		return
+	}
+	defer file.Close()
+
+	for _, commit := range commits {
+		if _, err := fmt.Fprintf(file, "%s\t%d\t%s\t%s\n", run, iteration, commit, iterationTicket); err != nil {
+			statusf("⚠️  Warning: failed to write %s: %v\n", IterationLogFile, err)
+			return
+		}
+	}
+}
+
+// loadIterationLog parses the iteration log; a missing log is an empty one
+func loadIterationLog() ([]IterationCommit, error) {
+	content, err := os.ReadFile(IterationLogFile)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []IterationCommit
+	for _, line := range strings.Split(string(content), "\n") {
+		fields := strings.Split(line, "\t")
+		if len(fields) < 3 {
+			continue
+		}
+		iteration, err := strconv.Atoi(fields[1])
+		if err != nil {
+			continue
+		}
+		entry := IterationCommit{Run: fields[0], Iteration: iteration, Commit: fields[2]}
+		if len(fields) > 3 {
+			entry.Ticket = fields[3]
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// selectIterationCommits picks the commits --show displays for target: an iteration number selects that
+// iteration of the most recent run that recorded it, anything else is a ticket identifier (all its commits)
+func selectIterationCommits(entries []IterationCommit, target string) []string {
+	var commits []string
+	iteration, err := strconv.Atoi(target)
+	if err != nil {
+		for _, entry := range entries {
+			if strings.EqualFold(entry.Ticket, target) {
+				commits = append(commits, entry.Commit)
+			}
+		}
+		return commits
+	}
+
+	run := ""
+	for _, entry := range entries {
+		if entry.Iteration == iteration {
+			run = entry.Run
+		}
+	}
+	for _, entry := range entries {
+		if entry.Run == run && entry.Iteration == iteration {
+			commits = append(commits, entry.Commit)
+		}
+	}
+	return commits
+}
+
+// showIteration runs git show for the commits recorded for an iteration number or ticket identifier
+func showIteration(target string) error {
+	entries, err := loadIterationLog()
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %v", IterationLogFile, err)
+	}
+	commits := selectIterationCommits(entries, target)
+	if len(commits) == 0 {
+		return fmt.Errorf("no commits recorded for %s in %s", target, IterationLogFile)
+	}
+
+	cmd := exec.Command("git", append([]string{"show"}, commits...)...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("git show failed: %v", err)
+	}
+	return nil
+}
+
+// Placeholders in commit_trailers values
+const (
+	TrailerIterationPlaceholder = "{{iteration}}" // Iteration number
+	TrailerRunPlaceholder       = "{{run}}"       // Run ID, as in .ralph/iterations.log
+	TrailerTicketPlaceholder    = "{{ticket}}"    // Ticket identifier in manager mode (ENG-123); empty otherwise
+	TrailerModelPlaceholder     = "{{model}}"     // $ANTHROPIC_MODEL, the model override the Claude CLI honours; empty if unset
+)
+
+// trailerKeyPattern matches a valid git trailer key (Ralph-Iteration, Linear-Issue)
+var trailerKeyPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9-]*$`)
+
+// validateCommitTrailers checks the commit_trailers keys and the placeholders in their values
+func validateCommitTrailers(trailers map[string]string) error {
+	for key, value := range trailers {
+		if !trailerKeyPattern.MatchString(key) {
+			return fmt.Errorf("commit_trailers key %q is not a valid trailer name (letters, digits and hyphens) in %s", key, RalphConfigFile)
+		}
+		if strings.ContainsAny(value, "\r\n") {
+			return fmt.Errorf("commit_trailers value for %s must be a single line in %s", key, RalphConfigFile)
+		}
+		for _, placeholder := range branchPlaceholderPattern.FindAllString(value, -1) {
+			switch placeholder {
+			case TrailerIterationPlaceholder, TrailerRunPlaceholder, TrailerTicketPlaceholder, TrailerModelPlaceholder:
+			default:
+				return fmt.Errorf("commit_trailers value for %s has unknown placeholder %s (valid: %s, %s, %s, %s) in %s", key, placeholder,
+					TrailerIterationPlaceholder, TrailerRunPlaceholder, TrailerTicketPlaceholder, TrailerModelPlaceholder, RalphConfigFile)
+			}
+		}
+	}
+	return nil
+}
+
+// addCommitTrailers amends the commit the commit step made (HEAD, when it moved from headBefore) to carry the
+// configured commit_trailers, so each commit links back to the run and ticket whatever message Claude wrote.
+// Trailers whose value expands to nothing (e.g. {{ticket}} outside manager mode) are left out. Failures only warn.
+func addCommitTrailers(headBefore string, iteration int) {
+	if len(ralphConfig.CommitTrailers) == 0 || headBefore == "" {
+		return
+	}
+	if head := getHeadCommit(); head == "" || head == headBefore {
+		return
+	}
+
+	replacer := strings.NewReplacer(
+		TrailerIterationPlaceholder, strconv.Itoa(iteration),
+		TrailerRunPlaceholder, currentRunID,
+		TrailerTicketPlaceholder, iterationTicket,
+		TrailerModelPlaceholder, os.Getenv("ANTHROPIC_MODEL"),
+	)
+	keys := make([]string, 0, len(ralphConfig.CommitTrailers))
+	for key := range ralphConfig.CommitTrailers {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	args := []string{"commit", "--amend", "--no-edit", "--no-verify", "--allow-empty"}
+	for _, key := range keys {
+		value := strings.TrimSpace(replacer.Replace(ralphConfig.CommitTrailers[key]))
+		if value != "" {
+			args = append(args, "--trailer", key+": "+value)
+		}
+	}
+	if len(args) == 5 {
+		return
+	}
+	if output, err := gitCombinedOutput(args...); err != nil {
+		statusf("⚠️  Warning: failed to add commit trailers: %v: %s\n", err, strings.TrimSpace(string(output)))
+	}
+}
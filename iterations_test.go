@@ -0,0 +1,17 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNewRunIDIsUniqueWithinASecond(t *testing.T) {
+	useFakeClock(t)
+	first, second := newRunID(), newRunID()
+	if first == second {
+		t.Errorf("two runs started at the same time got the same ID %q", first)
+	}
+	if !strings.HasPrefix(first, "20260101T090000-") {
+		t.Errorf("newRunID() = %q, want the start time as its prefix", first)
+	}
+}
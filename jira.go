@@ -0,0 +1,430 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// JiraClient handles Jira Cloud REST API interactions for manager mode
+type JiraClient struct {
+	BaseURL  string            // Site URL, e.g. https://yourcompany.atlassian.net
+	Email    string            // Account e-mail for API token (basic) authentication
+	Token    string            // API token
+	Project  string            // Project key
+	JQL      string            // Query for Todo tickets; defaults to the project's "Todo" status
+	Statuses map[string]string // Ralph state name -> Jira status name
+}
+
+// DefaultJiraStatuses maps Ralph's state names to the statuses of Jira's default workflow
+var DefaultJiraStatuses = map[string]string{
+	"Todo":        "To Do",
+	"In Progress": "In Progress",
+	"Done":        "Done",
+}
+
+// jiraIssueFields lists the fields requested for tickets
+const jiraIssueFields = "summary,description,priority,status,labels,project,assignee,created,updated,duedate"
+
+// jiraIssue is the subset of a Jira issue we use
+type jiraIssue struct {
+	Key    string `json:"key"`
+	Fields struct {
+		Summary     string `json:"summary"`
+		Description string `json:"description"`
+		Priority    *struct {
+			ID string `json:"id"`
+		} `json:"priority"`
+		Status struct {
+			ID   string `json:"id"`
+			Name string `json:"name"`
+		} `json:"status"`
+		Labels  []string `json:"labels"`
+		Project struct {
+			ID   string `json:"id"`
+			Key  string `json:"key"`
+			Name string `json:"name"`
+		} `json:"project"`
+		Assignee *struct {
+			AccountID    string `json:"accountId"`
+			DisplayName  string `json:"displayName"`
+			EmailAddress string `json:"emailAddress"`
+		} `json:"assignee"`
+		Created string  `json:"created"`
+		Updated string  `json:"updated"`
+		DueDate *string `json:"duedate"`
+	} `json:"fields"`
+}
+
+// jiraErrorResponse is the error envelope returned by the Jira API
+type jiraErrorResponse struct {
+	ErrorMessages []string          `json:"errorMessages"`
+	Errors        map[string]string `json:"errors"`
+}
+
+// jiraBranchPattern matches jira/{KEY-123}-{slug}
+var jiraBranchPattern = regexp.MustCompile(`^jira/([A-Z][A-Z0-9_]*-[0-9]+)(?:-|$)`)
+
+// NewJiraClient creates a Jira client from the manager config. The API token falls back to JIRA_API_TOKEN.
+func NewJiraClient(config *LinearConfig) *JiraClient {
+	token := config.JiraToken
+	if token == "" {
+		token = os.Getenv("JIRA_API_TOKEN")
+	}
+
+	statuses := make(map[string]string)
+	for state, status := range DefaultJiraStatuses {
+		statuses[state] = status
+	}
+	for state, status := range config.JiraStatuses {
+		statuses[state] = status
+	}
+
+	return &JiraClient{
+		BaseURL:  strings.TrimSuffix(config.JiraURL, "/"),
+		Email:    config.JiraEmail,
+		Token:    token,
+		Project:  config.Project,
+		JQL:      config.JiraJQL,
+		Statuses: statuses,
+	}
+}
+
+// Name returns the tracker name
+func (j *JiraClient) Name() string {
+	return "Jira"
+}
+
+// BranchPrefix returns the prefix of ticket branches (jira/{KEY-123}-{slug})
+func (j *JiraClient) BranchPrefix() string {
+	return "jira"
+}
+
+// issueIDFromBranch extracts the Jira issue key from a branch name, or returns ""
+func (j *JiraClient) issueIDFromBranch(branchName string) string {
+	if matches := jiraBranchPattern.FindStringSubmatch(branchName); matches != nil {
+		return matches[1]
+	}
+	return ""
+}
+
+// status returns the Jira status name for one of Ralph's state names
+func (j *JiraClient) status(stateName string) string {
+	if status, ok := j.Statuses[stateName]; ok {
+		return status
+	}
+	return stateName
+}
+
+// fetchTodoTickets fetches tickets matching jira_jql, or the project's Todo status, highest priority first
+func (j *JiraClient) fetchTodoTickets(projectKey string) ([]Issue, error) {
+	jql := j.JQL
+	if jql == "" {
+		jql = fmt.Sprintf(`project = "%s" AND status = "%s" ORDER BY priority DESC, created ASC`, projectKey, j.status("Todo"))
+	}
+
+	query := url.Values{}
+	query.Set("jql", jql)
+	query.Set("fields", jiraIssueFields)
+	query.Set("maxResults", "50")
+
+	body, err := j.doRequest("GET", "/rest/api/2/search/jql?"+query.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search issues: %v", err)
+	}
+
+	var result struct {
+		Issues []jiraIssue `json:"issues"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse issues: %v", err)
+	}
+
+	issues := make([]Issue, 0, len(result.Issues))
+	for _, issue := range result.Issues {
+		issues = append(issues, j.toIssue(issue))
+	}
+	return issues, nil
+}
+
+// getIssue fetches a single ticket by key, or returns nil if it doesn't exist
+func (j *JiraClient) getIssue(issueKey string) (*Issue, error) {
+	issue, err := j.fetchIssue(issueKey)
+	if err != nil || issue == nil {
+		return nil, err
+	}
+	converted := j.toIssue(*issue)
+	return &converted, nil
+}
+
+// fetchIssue fetches the raw Jira issue, or returns nil if it doesn't exist
+func (j *JiraClient) fetchIssue(issueKey string) (*jiraIssue, error) {
+	body, err := j.doRequest("GET", "/rest/api/2/issue/"+url.PathEscape(issueKey)+"?fields="+jiraIssueFields, nil)
+	if err != nil {
+		if strings.HasPrefix(err.Error(), "HTTP 404") {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var issue jiraIssue
+	if err := json.Unmarshal(body, &issue); err != nil {
+		return nil, fmt.Errorf("failed to parse issue: %v", err)
+	}
+	return &issue, nil
+}
+
+// toIssue maps a Jira issue onto Ralph's ticket model. The project stands in for the team,
+// and the priority ID (1 = Highest) for Linear's priority number.
+func (j *JiraClient) toIssue(issue jiraIssue) Issue {
+	var converted Issue
+	converted.ID = issue.Key
+	converted.Identifier = issue.Key
+	converted.Title = issue.Fields.Summary
+	converted.Description = issue.Fields.Description
+	if issue.Fields.Priority != nil {
+		converted.Priority, _ = strconv.ParseFloat(issue.Fields.Priority.ID, 64)
+	}
+	converted.State.ID = issue.Fields.Status.ID
+	converted.State.Name = issue.Fields.Status.Name
+	converted.Team.ID = issue.Fields.Project.Key
+	converted.Team.Key = issue.Fields.Project.Key
+	converted.Team.Name = issue.Fields.Project.Name
+	if issue.Fields.Assignee != nil {
+		converted.Assignee = &struct {
+			ID          string
+			Name        string
+			DisplayName string
+			Email       string
+		}{
+			ID:          issue.Fields.Assignee.AccountID,
+			Name:        issue.Fields.Assignee.DisplayName,
+			DisplayName: issue.Fields.Assignee.DisplayName,
+			Email:       issue.Fields.Assignee.EmailAddress,
+		}
+	}
+	for _, label := range issue.Fields.Labels {
+		converted.Labels.Nodes = append(converted.Labels.Nodes, struct {
+			ID   string
+			Name string
+		}{ID: label, Name: label})
+	}
+	converted.CreatedAt = issue.Fields.Created
+	converted.UpdatedAt = issue.Fields.Updated
+	converted.DueDate = issue.Fields.DueDate
+	converted.URL = j.BaseURL + "/browse/" + issue.Key
+	return converted
+}
+
+// updateTicketStatus moves a ticket to the Jira status for stateName via an available transition
+func (j *JiraClient) updateTicketStatus(issueKey, teamID, stateName string) error {
+	target := j.status(stateName)
+	path := "/rest/api/2/issue/" + url.PathEscape(issueKey) + "/transitions"
+
+	body, err := j.doRequest("GET", path, nil)
+	if err != nil {
+		return fmt.Errorf("failed to get transitions: %v", err)
+	}
+
+	var result struct {
+		Transitions []struct {
+			ID   string `json:"id"`
+			Name string `json:"name"`
+			To   struct {
+				Name string `json:"name"`
+			} `json:"to"`
+		} `json:"transitions"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return fmt.Errorf("failed to parse transitions: %v", err)
+	}
+
+	var available []string
+	for _, transition := range result.Transitions {
+		if strings.EqualFold(transition.To.Name, target) {
+			payload := map[string]interface{}{
+				"transition": map[string]string{"id": transition.ID},
+			}
+			_, err := j.doRequest("POST", path, payload)
+			return err
+		}
+		available = append(available, transition.To.Name)
+	}
+	return fmt.Errorf("no transition to status %q available for %s (available: %s)", target, issueKey, strings.Join(available, ", "))
+}
+
+// verifyIssueState verifies that a ticket exists and is in the Jira status for expectedState
+func (j *JiraClient) verifyIssueState(issueKey, expectedState string) (bool, error) {
+	issue, err := j.fetchIssue(issueKey)
+	if err != nil || issue == nil {
+		return false, err
+	}
+	return strings.EqualFold(issue.Fields.Status.Name, j.status(expectedState)), nil
+}
+
+// addLabel adds a label to a ticket (Jira labels are free-form, so nothing needs creating)
+func (j *JiraClient) addLabel(issue *Issue, name string) error {
+	// Jira labels cannot contain spaces
+	label := strings.ReplaceAll(name, " ", "-")
+	payload := map[string]interface{}{
+		"update": map[string]interface{}{
+			"labels": []map[string]string{{"add": label}},
+		},
+	}
+	if _, err := j.doRequest("PUT", "/rest/api/2/issue/"+url.PathEscape(issue.ID), payload); err != nil {
+		return fmt.Errorf("failed to add label %s: %v", label, err)
+	}
+	return nil
+}
+
+// verifyUser checks that username is an existing Atlassian account ID
+func (j *JiraClient) verifyUser(username string) error {
+	if _, err := j.doRequest("GET", "/rest/api/2/user?accountId="+url.QueryEscape(username), nil); err != nil {
+		return fmt.Errorf("user '%s' not found: %v", username, err)
+	}
+	return nil
+}
+
+// addTicketComment adds a comment to a ticket, mentioning usernames (Atlassian account IDs)
+func (j *JiraClient) addTicketComment(issueKey, comment string, usernames []string) error {
+	_, err := j.createTicketComment(issueKey, comment, usernames)
+	return err
+}
+
+// createTicketComment adds a comment to a ticket and returns the new comment's ID
+func (j *JiraClient) createTicketComment(issueKey, comment string, usernames []string) (string, error) {
+	commentBody := comment
+	if len(usernames) > 0 {
+		var mentions []string
+		for _, username := range usernames {
+			mentions = append(mentions, fmt.Sprintf("[~accountid:%s]", username))
+		}
+		commentBody = strings.Join(mentions, " ") + "\n\n" + comment
+	}
+
+	payload := map[string]string{"body": commentBody}
+	body, err := j.doRequest("POST", "/rest/api/2/issue/"+url.PathEscape(issueKey)+"/comment", payload)
+	if err != nil {
+		return "", err
+	}
+
+	var result struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return "", fmt.Errorf("failed to parse comment: %v", err)
+	}
+	return result.ID, nil
+}
+
+// getCommentBody returns the current body of a comment
+func (j *JiraClient) getCommentBody(issueKey, commentID string) (string, error) {
+	body, err := j.doRequest("GET", "/rest/api/2/issue/"+url.PathEscape(issueKey)+"/comment/"+url.PathEscape(commentID), nil)
+	if err != nil {
+		return "", err
+	}
+
+	var result struct {
+		Body string `json:"body"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return "", fmt.Errorf("failed to parse comment: %v", err)
+	}
+	return result.Body, nil
+}
+
+// listTicketComments returns the ticket's most recent comments, oldest first
+func (j *JiraClient) listTicketComments(issueKey string) ([]TicketComment, error) {
+	body, err := j.doRequest("GET", "/rest/api/2/issue/"+url.PathEscape(issueKey)+"/comment?orderBy=-created&maxResults=100", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Comments []struct {
+			ID      string `json:"id"`
+			Body    string `json:"body"`
+			Created string `json:"created"`
+		} `json:"comments"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse comments: %v", err)
+	}
+
+	var comments []TicketComment
+	for _, comment := range result.Comments {
+		created, err := time.Parse("2006-01-02T15:04:05.000-0700", comment.Created)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse comment time %q: %v", comment.Created, err)
+		}
+		comments = append(comments, TicketComment{ID: comment.ID, Body: comment.Body, CreatedAt: created})
+	}
+	sortTicketComments(comments)
+	return comments, nil
+}
+
+// updateTicketComment replaces the body of an existing comment
+func (j *JiraClient) updateTicketComment(issueKey, commentID, body string) error {
+	payload := map[string]string{"body": body}
+	_, err := j.doRequest("PUT", "/rest/api/2/issue/"+url.PathEscape(issueKey)+"/comment/"+url.PathEscape(commentID), payload)
+	return err
+}
+
+// doRequest performs an authenticated Jira API request and returns the response body
+func (j *JiraClient) doRequest(method, path string, payload interface{}) ([]byte, error) {
+	var reqBody io.Reader
+	if payload != nil {
+		jsonData, err := json.Marshal(payload)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal request: %v", err)
+		}
+		reqBody = bytes.NewBuffer(jsonData)
+	}
+
+	req, err := http.NewRequest(method, j.BaseURL+path, reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %v", err)
+	}
+
+	req.Header.Set("Accept", "application/json")
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.SetBasicAuth(j.Email, j.Token)
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %v", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var apiErr jiraErrorResponse
+		if json.Unmarshal(body, &apiErr) == nil {
+			messages := apiErr.ErrorMessages
+			for field, message := range apiErr.Errors {
+				messages = append(messages, fmt.Sprintf("%s: %s", field, message))
+			}
+			if len(messages) > 0 {
+				return nil, fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.Join(messages, "; "))
+			}
+		}
+		return nil, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+
+	return body, nil
+}
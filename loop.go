@@ -1,50 +1,79 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"os"
-	"os/exec"
+	"os/signal"
 	"strings"
+	"syscall"
+	"time"
 )
 
-// getUncommittedFiles gets list of uncommitted files
+// errBlocked is wrapped by loop errors that mean a blocker was reported, as opposed to a failure
+var errBlocked = errors.New("blocked")
+
+// getUncommittedFiles gets list of uncommitted files.
+// Uses NUL-separated output so paths with spaces, quotes or non-ASCII characters come through unquoted.
 func getUncommittedFiles() []string {
-	cmd := exec.Command("git", "status", "--porcelain")
-	output, err := cmd.Output()
+	output, err := gitOutput("status", "--porcelain", "-z")
 	if err != nil {
 		return []string{}
 	}
-	
-	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+
+	entries := splitNUL(output)
 	var result []string
-	for _, line := range lines {
-		if len(line) > 3 {
-			// Git status format: "XY filename"
-			filename := strings.TrimSpace(line[3:])
-			if filename != "" {
-				result = append(result, filename)
-			}
+	for i := 0; i < len(entries); i++ {
+		entry := entries[i]
+		if len(entry) < 4 {
+			continue
+		}
+		// Git status format: "XY filename"; renames and copies are followed by an entry with the original path
+		status := entry[:2]
+		result = append(result, entry[3:])
+		if strings.ContainsAny(status, "RC") {
+			i++
 		}
 	}
 	return result
 }
 
-// countIncompletePRDTasks parses .ralph/PRD.md and counts incomplete tasks (tasks with "- [ ]")
-func countIncompletePRDTasks() (int, error) {
-	content, err := os.ReadFile(".ralph/PRD.md")
+// getHeadCommit returns the current HEAD commit hash, or "" if it cannot be determined
+func getHeadCommit() string {
+	output, err := gitOutput("rev-parse", "HEAD")
 	if err != nil {
-		return 0, err
+		return ""
 	}
+	return strings.TrimSpace(string(output))
+}
 
-	// Count lines matching "- [ ]" pattern (incomplete tasks)
+// countIncompletePRDTasks counts the incomplete tasks (tasks with "- [ ]") of the parsed PRD
+func countIncompletePRDTasks(tasks []PRDTask) int {
 	count := 0
-	lines := strings.Split(string(content), "\n")
-	for _, line := range lines {
-		if strings.Contains(line, "- [ ]") {
+	for _, task := range tasks {
+		if !task.Done {
 			count++
 		}
 	}
-	return count, nil
+	return count
+}
+
+// iterationCooldown pauses before the next iteration (iteration_cooldown_seconds). An interrupt or termination
+// signal during the pause ends the run; the state is kept, so --continue picks it up.
+func iterationCooldown(next int) error {
+	seconds := ralphConfig.IterationCooldownSeconds
+	statusf("⏸️  Cooling down for %ds before iteration %d (iteration_cooldown_seconds)...\n", seconds, next)
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(interrupt)
+
+	select {
+	case <-clock.After(time.Duration(seconds) * time.Second):
+		return nil
+	case sig := <-interrupt:
+		return fmt.Errorf("interrupted (%v) during the cooldown before iteration %d", sig, next)
+	}
 }
 
 // executeRalphWorkflow runs the main Ralph workflow loop
@@ -53,16 +82,65 @@ func countIncompletePRDTasks() (int, error) {
 //   - maxIterations: maximum number of iterations to run
 //   - progressCallback: optional callback function called after each iteration (for manager mode)
 func executeRalphWorkflow(maxIterations int, progressCallback ProgressCallback) (bool, error) {
+	return executeRalphWorkflowFrom(1, 1, maxIterations, progressCallback)
+}
+
+// executeRalphWorkflowFrom runs the workflow loop starting at startIteration (used by --continue and resume overrides).
+// startStep applies to the first iteration only: 1 = Workflow 1, 2 = Workflow 2, 3 = task check after Workflow 2.
+func executeRalphWorkflowFrom(startIteration int, startStep int, maxIterations int, progressCallback ProgressCallback) (bool, error) {
 	// Verify required files exist
-	for _, filename := range RequiredFiles {
-		if _, err := os.Stat(filename); os.IsNotExist(err) {
+	for _, filename := range requiredFiles(ModeLoop) {
+		if !requiredFileExists(filename) {
 			return false, fmt.Errorf("required file %s not found", filename)
 		}
 	}
 
+	// Empty passes only count within one run (fail_on_empty_commit), not across manager-mode tickets
+	emptyCommitStreak = 0
+
+	// Pre-run hook can veto the run (e.g. dirty tree, wrong branch, lint failures)
+	if ralphConfig.PreRunHook != "" {
+		if err := runHook("pre-run", ralphConfig.PreRunHook, 0, maxIterations); err != nil {
+			return false, fmt.Errorf("aborting: %v", err)
+		}
+	}
+
+	// CLI upgrades are a common cause of cryptic step failures, so say up front which version runs
+	checkClaudeVersion()
+
+	// Agent runs after the loop (e.g. manager-mode PRD creation for the next ticket) cold-start
+	defer endAgentSession()
+
+	// Changes since here are what the final guardrail sweep reviews (guardrail_mode final/both)
+	runBase := getHeadCommit()
+
+	// Identifies this run's entries in the iteration log and its commit trailers
+	currentRunID = newRunID()
+
+	// Whatever ends the run short of completion (limit, blocker, error), show what's left from the PRD itself
+	completed := false
+	defer func() {
+		if !completed {
+			if _, err := printCriteriaReport(); err != nil {
+				statusf("⚠️  Warning: %v\n", err)
+			}
+		}
+	}()
+
 	// Main loop
-	for i := 1; i <= maxIterations; i++ {
-		fmt.Printf("🔄 Iteration %d/%d\n", i, maxIterations)
+	for i := startIteration; i <= maxIterations; i++ {
+		// Pace back-to-back iterations instead of running into rate limits and retry storms
+		if i > startIteration && ralphConfig.IterationCooldownSeconds > 0 {
+			if err := iterationCooldown(i); err != nil {
+				return false, err
+			}
+		}
+
+		headerf("🔄 Iteration %d/%d\n", i, maxIterations)
+		iterationBase := getHeadCommit()
+
+		// Keep the learnings file, and so every step's context, bounded on long runs
+		summarizeProgress()
 
 		// Save state at iteration start
 		state := &State{
@@ -82,19 +160,45 @@ func executeRalphWorkflow(maxIterations int, progressCallback ProgressCallback)
 			return false, fmt.Errorf("error saving state: %v", err)
 		}
 
+		// Resuming past Workflow 1 (first iteration only)
+		skipWorkflow1 := i == startIteration && startStep >= 2
+		skipWorkflow2 := i == startIteration && startStep >= 3
+		if skipWorkflow1 {
+			statusf("⏭️  Skipping Workflow 1 (resuming at %s)\n", getStepName(startStep))
+		}
+
 		// Loop Workflow 1 until PRD is complete
-		for {
+		for !skipWorkflow1 {
+			// A deleted or corrupted PRD must stop the run rather than look like an empty (finished) one
+			if _, err := loadPRDTasksMidRun("during Workflow 1"); err != nil {
+				return false, err
+			}
+
+			// Go-side completion check: every top-level task checked off counts as complete,
+			// even if Claude never emitted the completion promise
+			if prdTopLevelTasksDone() {
+				statusf("✅ PRD complete! (all top-level tasks checked off)\n")
+				break
+			}
+
 			result, err := workflow1PlanAndImplement(i, maxIterations)
 			if err != nil {
-				return false, fmt.Errorf("error in Workflow 1: %v", err)
+				return false, fmt.Errorf("error in Workflow 1: %w", err)
+			}
+
+			if result.Guardrails != nil {
+				statusf("🛡️  Guardrails: %s\n", result.Guardrails)
+				for _, finding := range result.Guardrails.Findings {
+					fmt.Printf("   - %s\n", finding)
+				}
 			}
 
 			if result.Blocked {
-				return false, fmt.Errorf("blocked during planning")
+				return false, fmt.Errorf("%w during planning", errBlocked)
 			}
 
 			if result.Complete {
-				fmt.Printf("✅ PRD complete!\n")
+				statusf("✅ PRD complete!\n")
 				break // Exit Workflow 1 loop
 			}
 
@@ -114,13 +218,19 @@ func executeRalphWorkflow(maxIterations int, progressCallback ProgressCallback)
 			return false, fmt.Errorf("error saving state: %v", err)
 		}
 
-		// Count incomplete tasks before Workflow 2
-		tasksBefore, _ := countIncompletePRDTasks()
+		// Count incomplete tasks before Workflow 2 (and snapshot them to report additions)
+		prdTasksBefore, err := loadPRDTasksMidRun("after Workflow 1")
+		if err != nil {
+			return false, err
+		}
+		tasksBefore := countIncompletePRDTasks(prdTasksBefore)
 
 		// Run Workflow 2
-		err := workflow2CleanupAndReview(i, maxIterations)
-		if err != nil {
-			return false, fmt.Errorf("error in Workflow 2: %v", err)
+		if skipWorkflow2 {
+			// Resuming at the task check: any incomplete task means another pass is needed
+			tasksBefore = 0
+		} else if err := workflow2CleanupAndReview(i, maxIterations); err != nil {
+			return false, fmt.Errorf("error in Workflow 2: %w", err)
 		}
 
 		state.LastCompletedWorkflow = 2
@@ -128,11 +238,36 @@ func executeRalphWorkflow(maxIterations int, progressCallback ProgressCallback)
 			return false, fmt.Errorf("error saving state: %v", err)
 		}
 
+		prdTasksAfter, err := loadPRDTasksMidRun("after Workflow 2")
+		if err != nil {
+			return false, err
+		}
+
+		// Report tasks Workflow 2 (e.g. self-improvement) added to the PRD
+		var addedTasks []string
+		if !skipWorkflow2 {
+			for _, task := range addedPRDTasks(prdTasksBefore, prdTasksAfter) {
+				title := prdTaskTitle(task)
+				addedTasks = append(addedTasks, title)
+				statusf("📝 Workflow 2 added task: %q\n", title)
+			}
+		}
+
+		recordIterationCommits(currentRunID, i, iterationBase)
+
+		// Post-iteration hook (non-fatal)
+		if ralphConfig.PostIterationHook != "" {
+			if err := runHook("post-iteration", ralphConfig.PostIterationHook, i, maxIterations); err != nil {
+				statusf("⚠️  Warning: %v\n", err)
+			}
+		}
+
 		// Gather progress information and call callback (for manager mode)
 		if progressCallback != nil {
 			progress := IterationProgress{
 				Iteration:     i,
 				MaxIterations: maxIterations,
+				AddedTasks:    addedTasks,
 			}
 
 			// Determine which workflows were completed
@@ -158,25 +293,152 @@ func executeRalphWorkflow(maxIterations int, progressCallback ProgressCallback)
 			// Call the progress callback
 			if err := progressCallback(progress); err != nil {
 				// Log error but don't fail the iteration
-				fmt.Printf("⚠️  Warning: progress callback failed: %v\n", err)
+				statusf("⚠️  Warning: progress callback failed: %v\n", err)
 			}
 		}
 
 		// Count incomplete tasks after Workflow 2
-		tasksAfter, _ := countIncompletePRDTasks()
+		prdTasksAfter, err = loadPRDTasksMidRun("after the iteration")
+		if err != nil {
+			return false, err
+		}
+		tasksAfter := countIncompletePRDTasks(prdTasksAfter)
 
 		// If new tasks were created, continue loop (go back to Workflow 1)
 		if tasksAfter > tasksBefore {
-			fmt.Printf("📝 Workflow 2 created %d new PRD task(s), continuing loop...\n", tasksAfter-tasksBefore)
+			statusf("📝 Workflow 2 created %d new PRD task(s), continuing loop...\n", tasksAfter-tasksBefore)
 			continue
 		}
 
+		// One guardrail pass over the whole run before declaring completion (and before any manager-mode PR)
+		if guardrailsExists() && guardrailFinalSweep() {
+			report, err := finalGuardrailSweep(runBase)
+			if err != nil {
+				return false, fmt.Errorf("error in final guardrail sweep: %w", err)
+			}
+			statusf("🛡️  Final guardrails: %s\n", report)
+			for _, finding := range report.Findings {
+				fmt.Printf("   - %s\n", finding)
+			}
+			if report.Blocked {
+				return false, fmt.Errorf("%w by the final guardrail sweep", errBlocked)
+			}
+		}
+
+		// Final acceptance check: "complete" means done_command passes, not just that Claude said so
+		if ralphConfig.DoneCommand != "" {
+			passed, err := doneGate()
+			if err != nil {
+				return false, fmt.Errorf("error in definition of done: %w", err)
+			}
+			if !passed {
+				statusf("🔁 Definition of done failed, continuing loop with the added task...\n")
+				continue
+			}
+		}
+
 		// No new tasks, PRD complete
+		completed = true
 		clearState()
 		return true, nil
 	}
 
-	// Iteration limit reached
-	clearState()
+	// Iteration limit reached: keep the state (marked as a finished iteration) so --continue can extend the run,
+	// unless clear_state_on_limit asks for the old behavior
+	if ralphConfig.ClearStateOnLimit {
+		clearState()
+		return false, nil
+	}
+	state := &State{
+		Iteration:             maxIterations,
+		MaxIterations:         maxIterations,
+		CurrentStep:           2,
+		LastCompletedWorkflow: 2,
+	}
+	if err := saveState(state); err != nil {
+		statusf("⚠️  Warning: failed to save state: %v\n", err)
+	}
 	return false, nil
 }
+
+// agentError marks a failed agent run (after retries), so it can be told apart from other errors (ExitAgent)
+type agentError struct{ err error }
+
+func (e *agentError) Error() string { return e.err.Error() }
+func (e *agentError) Unwrap() error { return e.err }
+
+// configError marks an error caused by invalid arguments or configuration (ExitConfig)
+type configError struct{ err error }
+
+func (e *configError) Error() string { return e.err.Error() }
+func (e *configError) Unwrap() error { return e.err }
+
+// exitCode maps the outcome of a run to its exit code
+func exitCode(completed bool, err error) int {
+	var agentErr *agentError
+	var configErr *configError
+	switch {
+	case errors.Is(err, errBlocked):
+		return ExitBlocked
+	case errors.As(err, &agentErr):
+		return ExitAgent
+	case errors.As(err, &configErr):
+		return ExitConfig
+	case err != nil:
+		return ExitError
+	case completed:
+		return ExitComplete
+	default:
+		return ExitLimit
+	}
+}
+
+// exitRun prints the outcome of a loop run and exits with its exit code
+func exitRun(completed bool, err error, maxIterations int) {
+	code := exitCode(completed, err)
+	switch code {
+	case ExitBlocked:
+		errorf("🚫 Run %v\n", err)
+	case ExitComplete:
+		statusf("✅ PRD completed successfully!\n")
+	case ExitLimit:
+		if ralphConfig.ClearStateOnLimit {
+			statusf("⚠️  Reached iteration limit (%d) but PRD not yet complete. State cleared (clear_state_on_limit); a new run starts from iteration 1.\n", maxIterations)
+		} else {
+			statusf("⚠️  Reached iteration limit (%d) but PRD not yet complete. Use --continue to run more iterations.\n", maxIterations)
+		}
+	case ExitAgent:
+		// The agent's error was printed in full by executeStepWithRetry
+		if claudeVersion != "" {
+			errorf("❌ Run failed: the agent step failed (details above; Claude CLI %s)\n", claudeVersion)
+		} else {
+			errorf("❌ Run failed: the agent step failed (details above)\n")
+		}
+	default:
+		// Step errors were already printed with their context in steps.go; this is the one-line outcome
+		errorf("❌ Run failed: %v\n", err)
+	}
+	os.Exit(code)
+}
+
+// continueRun extends the budget of the previous run by extraIterations and resumes it.
+// An interrupted iteration is re-run from the start; a finished one moves on to the next iteration.
+func continueRun(extraIterations int) (bool, int, error) {
+	state, err := loadState()
+	if err != nil {
+		return false, 0, fmt.Errorf("failed to load state: %v", err)
+	}
+	if state == nil || state.Iteration == 0 || state.MaxIterations == 0 {
+		return false, 0, fmt.Errorf("no previous run to continue (%s not found); start one with: ralph <iterations>", StateFile)
+	}
+
+	startIteration := state.Iteration
+	if state.LastCompletedWorkflow == 2 {
+		startIteration++
+	}
+	maxIterations := state.MaxIterations + extraIterations
+
+	statusf("🔄 Continuing from iteration %d (budget %d → %d)\n", startIteration, state.MaxIterations, maxIterations)
+	completed, err := executeRalphWorkflowFrom(startIteration, 1, maxIterations, nil)
+	return completed, maxIterations, err
+}
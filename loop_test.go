@@ -0,0 +1,157 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// initGitRepo runs the rest of the test in a fresh git repository with one commit
+func initGitRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not found in PATH")
+	}
+	dir := inTempDir(t)
+	runGit(t, "init", "-q")
+	runGit(t, "config", "user.email", "ralph@example.com")
+	runGit(t, "config", "user.name", "Ralph Test")
+	writeTestFile(t, "README.md", "# Test\n")
+	runGit(t, "add", "-A")
+	runGit(t, "commit", "-q", "-m", "Initial commit")
+	return dir
+}
+
+// runGit runs git in the current directory and returns its trimmed output
+func runGit(t *testing.T, args ...string) string {
+	t.Helper()
+	output, err := exec.Command("git", args...).CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s: %v: %s", strings.Join(args, " "), err, output)
+	}
+	return strings.TrimSpace(string(output))
+}
+
+// writeTestFile writes content to path, creating its directory
+func writeTestFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// stubAgentScript stands in for the coding agent. The step prompts are replaced by "step:<name>" so it can
+// tell the steps apart: implementation edits a file, commit checks off the PRD tasks and commits, and
+// self-improvement adds a task when ADD_TASK is set.
+const stubAgentScript = `case "$1" in
+*step:implementation*)
+	echo "work" >> work.txt ;;
+*step:commit*)
+	sed 's/- \[ \]/- [x]/' .ralph/PRD.md > .ralph/PRD.tmp && mv .ralph/PRD.tmp .ralph/PRD.md
+	git add work.txt .ralph/PRD.md && git commit -q -m "Implement the task" ;;
+*step:self-improvement*)
+	if [ -n "$ADD_TASK" ]; then echo "- [ ] Follow-up task" >> .ralph/PRD.md; fi ;;
+esac
+echo "done"
+`
+
+// setupStubLoop prepares a one-task PRD, step prompts and a config whose agent_command runs stubAgentScript
+func setupStubLoop(t *testing.T, dir string) {
+	t.Helper()
+	writeTestFile(t, SamplePRDFile, "# PRD\n\n- [ ] Write work.txt\n")
+	prompts := map[string]string{
+		PlanningPromptFile:        "planning",
+		ImplementationPromptFile:  "implementation",
+		CleanupPromptFile:         "cleanup",
+		SelfImprovementPromptFile: "self-improvement",
+		CommitPromptFile:          "commit",
+	}
+	for path, step := range prompts {
+		writeTestFile(t, path, "step:"+step+"\n")
+	}
+	script := filepath.Join(dir, ".ralph", "agent.sh")
+	writeTestFile(t, script, stubAgentScript)
+
+	config := defaultRalphConfig()
+	config.AgentCommand = "sh " + script + " {{prompt}}"
+	withRalphConfig(t, config)
+
+	previous := cliOptions
+	cliOptions.Quiet = true
+	t.Cleanup(func() { cliOptions = previous })
+}
+
+func TestExecuteRalphWorkflowCompletes(t *testing.T) {
+	dir := initGitRepo(t)
+	setupStubLoop(t, dir)
+	headBefore := getHeadCommit()
+
+	completed, err := executeRalphWorkflow(2, nil)
+	if err != nil {
+		t.Fatalf("executeRalphWorkflow: %v", err)
+	}
+	if !completed {
+		t.Fatal("run did not complete")
+	}
+
+	headAfter := getHeadCommit()
+	if headAfter == headBefore {
+		t.Fatal("HEAD did not move")
+	}
+	if subject := runGit(t, "log", "-1", "--format=%s"); subject != "Implement the task" {
+		t.Errorf("last commit = %q, want the stub's commit", subject)
+	}
+	if _, err := os.Stat(StateFile); !os.IsNotExist(err) {
+		t.Errorf("%s still exists after a completed run", StateFile)
+	}
+
+	entries, err := loadIterationLog()
+	if err != nil {
+		t.Fatalf("loadIterationLog: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Iteration != 1 || entries[0].Commit != headAfter {
+		t.Errorf("iteration log = %+v, want iteration 1 at %s", entries, headAfter)
+	}
+}
+
+func TestExecuteRalphWorkflowStopsAtLimit(t *testing.T) {
+	dir := initGitRepo(t)
+	setupStubLoop(t, dir)
+	t.Setenv("ADD_TASK", "1")
+	headBefore := getHeadCommit()
+
+	completed, err := executeRalphWorkflow(1, nil)
+	if err != nil {
+		t.Fatalf("executeRalphWorkflow: %v", err)
+	}
+	if completed {
+		t.Fatal("run completed, want it to stop at the iteration limit with the added task open")
+	}
+	if getHeadCommit() == headBefore {
+		t.Fatal("HEAD did not move")
+	}
+
+	state, err := loadState()
+	if err != nil {
+		t.Fatalf("loadState: %v", err)
+	}
+	if state == nil {
+		t.Fatalf("%s missing after the iteration limit", StateFile)
+	}
+	if state.Iteration != 1 || state.MaxIterations != 1 || state.LastCompletedWorkflow != 2 {
+		t.Errorf("state = %+v, want iteration 1/1 with Workflow 2 completed", state)
+	}
+
+	entries, err := loadIterationLog()
+	if err != nil {
+		t.Fatalf("loadIterationLog: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Iteration != 1 || entries[0].Run == "" {
+		t.Errorf("iteration log = %+v, want one commit of iteration 1", entries)
+	}
+}
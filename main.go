@@ -3,6 +3,7 @@ package main
 import (
 	"fmt"
 	"os"
+	"strconv"
 	"strings"
 )
 
@@ -11,27 +12,73 @@ func printHelp() {
 	fmt.Println()
 	fmt.Println("Usage:")
 	fmt.Printf("  %s <iterations>\n", os.Args[0])
+	fmt.Printf("  %s --continue <iterations>\n", os.Args[0])
+	fmt.Printf("  %s --resume-iteration <N> --resume-step <S>\n", os.Args[0])
+	fmt.Printf("  %s --only <self-improve|guardrail|refactor>\n", os.Args[0])
+	fmt.Printf("  %s --review-only\n", os.Args[0])
+	fmt.Printf("  %s --criteria\n", os.Args[0])
+	fmt.Printf("  %s --show <iteration|ticket>\n", os.Args[0])
+	fmt.Printf("  %s --migrate-state [--dry-run]\n", os.Args[0])
+	fmt.Printf("  %s --clean [--all] [--yes]\n", os.Args[0])
 	fmt.Printf("  %s --export-prompts\n", os.Args[0])
-	fmt.Printf("  %s --init [description]\n", os.Args[0])
+	fmt.Printf("  %s --init [--yes] [description | --from-file <path>]\n", os.Args[0])
 	fmt.Printf("  %s --init-guardrails\n", os.Args[0])
-	fmt.Printf("  %s --simplify-prd\n", os.Args[0])
-	fmt.Printf("  %s --manager <config-file> <iterations>\n", os.Args[0])
+	fmt.Printf("  %s --simplify-prd [passes]\n", os.Args[0])
+	fmt.Printf("  %s --manager <config-file> <iterations> [--dry-run] [--keep-going] [--once] [--on-empty <wait|exit|exit-after N>] [--metrics-file <path>]\n", os.Args[0])
 	fmt.Printf("  %s --tickets <config-file>\n", os.Args[0])
 	fmt.Printf("  %s --help\n", os.Args[0])
 	fmt.Printf("  %s -h\n", os.Args[0])
 	fmt.Printf("  %s --version\n", os.Args[0])
 	fmt.Printf("  %s -v\n", os.Args[0])
 	fmt.Println()
+	fmt.Println("Options (may be combined with any command):")
+	fmt.Println("  --quiet, -q       Suppress Claude's output; print only step-level status lines")
+	fmt.Println("  --force-refactor  Run the CLAUDE.md refactor step even when CLAUDE.md does not exist")
+	fmt.Println("  --keep-plans      Archive each plan to .ralph/plans/iter-N-<task>.md before cleanup removes it")
+	fmt.Println("  --verbose-git     Echo the git commands Ralph runs (branching, push, diffs) and their output")
+	fmt.Println("  --commit-per-step Commit after planning and implementation (wip(step): ...); the commit step squashes them")
+	fmt.Println("                    into its commit and keeps the step commits at refs/ralph/steps/iter-N for bisecting")
+	fmt.Println("  --strict-prd      Refuse to run when the PRD still contains template placeholders like [Task Name]")
+	fmt.Println("  --raw-stream-file <path>  Write each step's raw agent stdout to <path> with a -NNN-<step> suffix (debugging)")
+	fmt.Println("  --no-color        Don't color status lines (also NO_COLOR; color is only used on a terminal)")
+	fmt.Println()
 	fmt.Println("Commands:")
 	fmt.Println("  iterations        Number of iterations to run (must be >= 1)")
+	fmt.Println("  --continue        Resume the previous run and give it this many more iterations")
+	fmt.Println("                    (a fresh <iterations> run starts counting again from 1)")
+	fmt.Println("  --resume-iteration, --resume-step")
+	fmt.Println("                    Force resuming the saved run at iteration N, step S (1 = Workflow 1,")
+	fmt.Println("                    2 = Workflow 2, 3 = task check); max iterations come from the state file")
+	fmt.Println("  --only            Run a single workflow ad hoc: self-improve, guardrail (verify against GUARDRAILS.md)")
+	fmt.Println("                    or refactor (CLAUDE.md); only self-improve needs a PRD")
+	fmt.Println("  --review-only     Review the current tree (self-improvement + guardrail analysis) and write findings")
+	fmt.Println("                    to .ralph/REVIEW.md; no planning, implementation, code changes or commits")
+	fmt.Println("  --criteria        List the unchecked verification criteria of each incomplete PRD task")
+	fmt.Println("  --show            Show (git show) the commits the latest run made in an iteration, or all commits")
+	fmt.Println("                    recorded for a manager-mode ticket (e.g. ENG-123), from .ralph/iterations.log")
+	fmt.Println("  --migrate-state   Rewrite .ralph/ralph-state.txt from a legacy format, keeping an interrupted run's progress")
+	fmt.Println("                    --dry-run shows the key mapping without writing")
+	fmt.Println("  --clean           Remove run state and artifacts (state files, PLAN.md, PROGRESS.md); PRD and prompts are kept")
+	fmt.Println("                    --all also removes archived plans, progress history, the iteration log, the review report")
+	fmt.Println("                    and the files written by --raw-stream-file")
+	fmt.Println("                    --yes skips the confirmation prompt")
 	fmt.Println("  --export-prompts  Export all built-in prompts to .ralph directory for customization")
 	fmt.Println("  --init            Create minimum files needed to get started (.ralph/PRD.md)")
 	fmt.Println("                    If description is provided, interactively creates a PRD using Claude")
 	fmt.Println("  --init-guardrails Analyze the project and use Claude to generate a tailored GUARDRAILS.md")
 	fmt.Println("  --simplify-prd    Reprocess .ralph/PRD.md to simplify incomplete tasks (easy/medium, 15-20 min); completed tasks left unchanged")
-	fmt.Println("  --manager         Linear manager mode: automatically process tickets from Linear")
+	fmt.Println("                    Optional passes overrides simplify_passes from .ralph/config.toml")
+	fmt.Println("  --manager         Manager mode: automatically process tickets from Linear or Jira (tracker in config)")
 	fmt.Println("                    Requires config-file (TOML) and iterations parameter")
-	fmt.Println("  --tickets         List all pending tickets from Linear (for testing connectivity)")
+	fmt.Println("                    --dry-run prints the ticket, branch and PRD input it would use, then exits without changes")
+	fmt.Println("                    --keep-going escalates a failed ticket and continues with the next one, then prints a summary")
+	fmt.Println("                    --once processes one ticket (through its pull request) and exits; non-zero if it failed.")
+	fmt.Println("                    With no Todo ticket it exits 0 unless on_empty/--on-empty says otherwise")
+	fmt.Println("                    --metrics-file writes Prometheus textfile metrics (tickets, iterations, agent calls),")
+	fmt.Println("                    updated after each iteration and ticket")
+	fmt.Println("                    --on-empty sets what happens when there is no Todo ticket (overrides on_empty in the config):")
+	fmt.Println("                    wait (poll every minute), exit (exit 0), or \"exit-after N\" (exit 0 after N empty polls)")
+	fmt.Println("  --tickets         List pending tickets from Linear or Jira (for testing connectivity)")
 	fmt.Println("                    Requires config-file (TOML)")
 	fmt.Println("  --version, -v     Display version information")
 	fmt.Println()
@@ -40,14 +87,14 @@ func printHelp() {
 		fmt.Println("  - Step: Planning")
 		fmt.Println("  - Step: Implementation and Validation")
 		fmt.Println("  - Step: Cleanup and Documentation")
-		fmt.Println("  - Step: CLAUDE.md Refactoring")
+		fmt.Println("  - Step: CLAUDE.md Refactoring (only when CLAUDE.md exists, unless --force-refactor)")
 		fmt.Println("  - Step: Self-Improvement Analysis (every 5th iteration)")
 		fmt.Println("  - Step: Commit")
 	fmt.Println()
 	fmt.Println("Features:")
 	fmt.Println("  - Automatic resume from last checkpoint if interrupted")
 	fmt.Println("  - State persistence across runs")
-	fmt.Println("  - Configurable via .ralph directory")
+	fmt.Println("  - Configurable via .ralph directory (.ralph/config.toml for loop settings)")
 	fmt.Println()
 	fmt.Println("Required Files:")
 	fmt.Println("  - .ralph/PRD.md")
@@ -55,145 +102,492 @@ func printHelp() {
 	fmt.Println("Optional Files:")
 	fmt.Println("  - GUARDRAILS.md   When present at project root, Ralph verifies implementations against it after each implementation step. Use --init-guardrails to generate one from the project.")
 	fmt.Println()
+	fmt.Println("Environment (used when the corresponding arguments are absent):")
+	fmt.Println("  RALPH_MODE        loop (default) or manager")
+	fmt.Println("  RALPH_ITERATIONS  Iterations for loop mode, or per ticket in manager mode")
+	fmt.Println("  RALPH_CONFIG      Manager config file for --manager / --tickets")
+	fmt.Println()
 	fmt.Println("Prompt Customization:")
 	fmt.Println("  Use --export-prompts to export built-in prompts to .ralph directory.")
 	fmt.Println("  Customize prompts by editing files in .ralph/")
 }
 
+// CLIOptions holds option flags that may appear anywhere on the command line
+type CLIOptions struct {
+	Quiet         bool   // Suppress Claude's output; print only step-level status lines
+	ForceRefactor bool   // Run the CLAUDE.md refactor step even when CLAUDE.md does not exist
+	KeepPlans     bool   // Archive each PLAN.md to .ralph/plans/ before the cleanup step removes it
+	VerboseGit    bool   // Echo git commands run by Ralph and their output
+	CommitPerStep bool   // Commit after planning and implementation (wip commits, squashed by the commit step)
+	StrictPRD     bool   // Refuse to run when the PRD still contains sample-template placeholders
+	RawStreamFile string // Tee each agent run's raw stdout to a numbered file derived from this path
+	NoColor       bool   // Never color status lines, even on a terminal (NO_COLOR does the same)
+}
+
+// cliOptions is the parsed set of global option flags
+var cliOptions CLIOptions
+
+// parseGlobalFlags records recognized option flags in cliOptions and returns the remaining arguments
+func parseGlobalFlags(args []string) []string {
+	var rest []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if strings.HasPrefix(arg, "--raw-stream-file=") {
+			cliOptions.RawStreamFile = strings.TrimPrefix(arg, "--raw-stream-file=")
+			continue
+		}
+		switch arg {
+		case "--quiet", "-q":
+			cliOptions.Quiet = true
+		case "--force-refactor":
+			cliOptions.ForceRefactor = true
+		case "--keep-plans":
+			cliOptions.KeepPlans = true
+		case "--verbose-git":
+			cliOptions.VerboseGit = true
+		case "--commit-per-step":
+			cliOptions.CommitPerStep = true
+		case "--strict-prd":
+			cliOptions.StrictPRD = true
+		case "--no-color":
+			cliOptions.NoColor = true
+		case "--raw-stream-file":
+			if i+1 >= len(args) {
+				errorf("Error: --raw-stream-file requires a path\n")
+				os.Exit(ExitConfig)
+			}
+			i++
+			cliOptions.RawStreamFile = args[i]
+		default:
+			rest = append(rest, arg)
+		}
+	}
+	return rest
+}
+
+// applyEnvDefaults fills in arguments missing from the command line from RALPH_MODE (loop|manager),
+// RALPH_ITERATIONS and RALPH_CONFIG. Command-line arguments take precedence; values are validated by the usual parsing.
+func applyEnvDefaults(args []string) ([]string, error) {
+	envIterations := os.Getenv("RALPH_ITERATIONS")
+	envConfig := os.Getenv("RALPH_CONFIG")
+
+	if len(args) == 0 {
+		mode := os.Getenv("RALPH_MODE")
+		switch mode {
+		case "", "loop":
+			if envIterations == "" {
+				if mode == "loop" {
+					return nil, fmt.Errorf("RALPH_MODE=loop requires RALPH_ITERATIONS")
+				}
+				return args, nil
+			}
+			return []string{envIterations}, nil
+		case "manager":
+			if envConfig == "" || envIterations == "" {
+				return nil, fmt.Errorf("RALPH_MODE=manager requires RALPH_CONFIG and RALPH_ITERATIONS")
+			}
+			return []string{"--manager", envConfig, envIterations}, nil
+		default:
+			return nil, fmt.Errorf("invalid RALPH_MODE: %s (must be loop or manager)", mode)
+		}
+	}
+
+	// Fill in missing manager/tickets arguments (--dry-run is not positional)
+	positional := 0
+	for i := 0; i < len(args); i++ {
+		if args[i] == "--on-empty" {
+			i++
+			if i < len(args) && args[i] == "exit-after" && i+1 < len(args) && isOnEmptyCount(args[i+1]) {
+				i++
+			}
+		} else if args[i] == "--metrics-file" {
+			i++
+		} else if args[i] != "--dry-run" && args[i] != "--keep-going" && args[i] != "--once" &&
+			!strings.HasPrefix(args[i], "--on-empty=") && !strings.HasPrefix(args[i], "--metrics-file=") {
+			positional++
+		}
+	}
+	if (args[0] == "--manager" || args[0] == "--tickets") && positional < 2 && envConfig != "" {
+		args = append(args, envConfig)
+		positional++
+	}
+	if args[0] == "--manager" && positional == 2 && envIterations != "" {
+		args = append(args, envIterations)
+	}
+	return args, nil
+}
+
+// isOnEmptyCount reports whether arg is the N of "--on-empty exit-after N"
+func isOnEmptyCount(arg string) bool {
+	_, err := strconv.Atoi(arg)
+	return err == nil
+}
+
 func main() {
-	if len(os.Args) < 2 {
+	args := parseGlobalFlags(os.Args[1:])
+	initColor()
+
+	// Environment fallbacks for container deployments (RALPH_MODE, RALPH_ITERATIONS, RALPH_CONFIG)
+	args, err := applyEnvDefaults(args)
+	if err != nil {
+		errorf("Error: %v\n", err)
+		os.Exit(ExitConfig)
+	}
+
+	if len(args) < 1 {
 		fmt.Fprintf(os.Stderr, "Usage: %s <iterations> or %s --export-prompts or %s --init [description] or %s --init-guardrails or %s --simplify-prd or %s --manager <config-file> <iterations> or %s --tickets <config-file>\n", os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0])
 		fmt.Fprintf(os.Stderr, "Use --help or -h for more information, or --version/-v for version\n")
-		os.Exit(1)
+		os.Exit(ExitConfig)
 	}
 
 	// Check for help flag
-	if os.Args[1] == "--help" || os.Args[1] == "-h" {
+	if args[0] == "--help" || args[0] == "-h" {
 		printHelp()
 		os.Exit(0)
 	}
 
 	// Check for version flag
-	if os.Args[1] == "--version" || os.Args[1] == "-v" {
+	if args[0] == "--version" || args[0] == "-v" {
 		fmt.Printf("Ralph version %s\n", Version)
 		os.Exit(0)
 	}
 
+	// Load optional loop configuration (.ralph/config.toml)
+	if err := loadRalphConfig(); err != nil {
+		errorf("❌ Error: %v\n", err)
+		os.Exit(ExitConfig)
+	}
+
 	// Check for export-prompts flag
-	if os.Args[1] == "--export-prompts" {
+	if args[0] == "--export-prompts" {
 		if err := exportPrompts(); err != nil {
-			fmt.Fprintf(os.Stderr, "❌ Error exporting prompts: %v\n", err)
-			os.Exit(1)
+			errorf("❌ Error exporting prompts: %v\n", err)
+			os.Exit(ExitError)
 		}
 		os.Exit(0)
 	}
 
 	// Check for init flag
-	if os.Args[1] == "--init" {
-		// Check if description parameter is provided
-		description := ""
-		if len(os.Args) > 2 {
-			// Join all remaining args as the description (handles multi-word descriptions)
-			description = strings.Join(os.Args[2:], " ")
+	if args[0] == "--init" {
+		// Check if description parameter is provided; --yes/-y skips the overwrite confirmation
+		// and --from-file <path> reads the description from a file
+		yes := false
+		fromFile := ""
+		var words []string
+		for i := 1; i < len(args); i++ {
+			switch args[i] {
+			case "--yes", "-y":
+				yes = true
+			case "--from-file":
+				if i+1 >= len(args) {
+					fmt.Fprintf(os.Stderr, "Usage: %s --init --from-file <path>\n", os.Args[0])
+					os.Exit(ExitConfig)
+				}
+				i++
+				fromFile = args[i]
+			default:
+				words = append(words, args[i])
+			}
+		}
+		// Join all remaining args as the description (handles multi-word descriptions)
+		description := strings.Join(words, " ")
+		if fromFile != "" {
+			if description != "" {
+				errorf("❌ Error: pass either a description or --from-file, not both\n")
+				os.Exit(ExitConfig)
+			}
+			var err error
+			if description, err = descriptionFromFile(fromFile); err != nil {
+				errorf("❌ Error: %v\n", err)
+				os.Exit(ExitError)
+			}
 		}
-		if err := initProject(description); err != nil {
-			fmt.Fprintf(os.Stderr, "❌ Error initializing project: %v\n", err)
-			os.Exit(1)
+		if err := initProject(description, yes); err != nil {
+			errorf("❌ Error initializing project: %v\n", err)
+			os.Exit(ExitError)
 		}
 		os.Exit(0)
 	}
 
 	// Check for init-guardrails flag
-	if os.Args[1] == "--init-guardrails" {
+	if args[0] == "--init-guardrails" {
 		if err := initGuardrails(); err != nil {
-			fmt.Fprintf(os.Stderr, "❌ Error: %v\n", err)
-			os.Exit(1)
+			errorf("❌ Error: %v\n", err)
+			os.Exit(ExitError)
 		}
 		os.Exit(0)
 	}
 
 	// Check for simplify-prd flag
-	if os.Args[1] == "--simplify-prd" {
+	if args[0] == "--simplify-prd" {
+		// Optional passes argument overrides simplify_passes from config
+		if len(args) > 1 {
+			var passes int
+			if _, err := fmt.Sscanf(args[1], "%d", &passes); err != nil || passes < 1 {
+				errorf("Error: invalid passes value: %s (must be >= 1)\n", args[1])
+				os.Exit(ExitConfig)
+			}
+			ralphConfig.SimplifyPasses = passes
+		}
 		if err := reprocessPRD(); err != nil {
-			fmt.Fprintf(os.Stderr, "❌ Error: %v\n", err)
-			os.Exit(1)
+			errorf("❌ Error: %v\n", err)
+			os.Exit(ExitError)
 		}
 		os.Exit(0)
 	}
 
 	// Check for manager flag
-	if os.Args[1] == "--manager" {
-		if len(os.Args) < 4 {
-			fmt.Fprintf(os.Stderr, "Usage: %s --manager <config-file> <iterations>\n", os.Args[0])
-			fmt.Fprintf(os.Stderr, "  config-file: Path to Linear config TOML file\n")
+	if args[0] == "--manager" {
+		// --dry-run shows the ticket that would be picked without changing anything;
+		// --keep-going escalates a failed ticket and moves on instead of ending the session;
+		// --once processes a single ticket and exits (cron/CI), its outcome in the exit code;
+		// --on-empty chooses between waiting for tickets (daemon) and exiting (scheduled job);
+		// --metrics-file keeps a Prometheus textfile of the session's counters for monitoring a daemon
+		dryRun, keepGoing, once := false, false, false
+		onEmpty, metricsFile := "", ""
+		var managerArgs []string
+		for i := 0; i < len(args); i++ {
+			arg := args[i]
+			if arg == "--dry-run" {
+				dryRun = true
+			} else if arg == "--keep-going" {
+				keepGoing = true
+			} else if arg == "--once" {
+				once = true
+			} else if arg == "--on-empty" {
+				if i+1 >= len(args) {
+					errorf("Error: --on-empty requires a value (wait, exit, or \"exit-after N\")\n")
+					os.Exit(ExitConfig)
+				}
+				i++
+				onEmpty = args[i]
+				// Also accept the count as a separate argument: --on-empty exit-after 3 (only a number, so a
+				// missing count doesn't swallow the config file or iterations that follow)
+				if onEmpty == "exit-after" && i+1 < len(args) && isOnEmptyCount(args[i+1]) {
+					i++
+					onEmpty += " " + args[i]
+				}
+			} else if strings.HasPrefix(arg, "--on-empty=") {
+				onEmpty = strings.TrimPrefix(arg, "--on-empty=")
+			} else if arg == "--metrics-file" {
+				if i+1 >= len(args) {
+					errorf("Error: --metrics-file requires a path\n")
+					os.Exit(ExitConfig)
+				}
+				i++
+				metricsFile = args[i]
+			} else if strings.HasPrefix(arg, "--metrics-file=") {
+				metricsFile = strings.TrimPrefix(arg, "--metrics-file=")
+			} else {
+				managerArgs = append(managerArgs, arg)
+			}
+		}
+		args = managerArgs
+
+		if len(args) < 3 {
+			fmt.Fprintf(os.Stderr, "Usage: %s --manager <config-file> <iterations> [--dry-run] [--keep-going] [--once] [--on-empty <wait|exit|exit-after N>] [--metrics-file <path>]\n", os.Args[0])
+			fmt.Fprintf(os.Stderr, "  config-file: Path to manager config TOML file (Linear or Jira)\n")
 			fmt.Fprintf(os.Stderr, "  iterations:  Number of iterations to run per ticket (must be >= 1)\n")
-			os.Exit(1)
+			os.Exit(ExitConfig)
 		}
 
-		configFile := os.Args[2]
+		configFile := args[1]
 		var iterations int
-		if _, err := fmt.Sscanf(os.Args[3], "%d", &iterations); err != nil || iterations < 1 {
-			fmt.Fprintf(os.Stderr, "Error: invalid iterations value: %s (must be >= 1)\n", os.Args[3])
-			os.Exit(1)
+		if _, err := fmt.Sscanf(args[2], "%d", &iterations); err != nil || iterations < 1 {
+			errorf("Error: invalid iterations value: %s (must be >= 1)\n", args[2])
+			os.Exit(ExitConfig)
 		}
 
-		if err := runManagerMode(configFile, iterations); err != nil {
-			fmt.Fprintf(os.Stderr, "❌ Manager mode error: %v\n", err)
-			os.Exit(1)
+		if err := runManagerMode(configFile, iterations, dryRun, keepGoing, once, onEmpty, metricsFile); err != nil {
+			errorf("❌ Manager mode error: %v\n", err)
+			os.Exit(exitCode(false, err))
 		}
 		os.Exit(0)
 	}
 
 	// Check for tickets flag (test Linear connectivity)
-	if os.Args[1] == "--tickets" {
-		if len(os.Args) < 3 {
+	if args[0] == "--tickets" {
+		if len(args) < 2 {
 			fmt.Fprintf(os.Stderr, "Usage: %s --tickets <config-file>\n", os.Args[0])
-			fmt.Fprintf(os.Stderr, "  config-file: Path to Linear config TOML file\n")
-			os.Exit(1)
+			fmt.Fprintf(os.Stderr, "  config-file: Path to manager config TOML file (Linear or Jira)\n")
+			os.Exit(ExitConfig)
 		}
 
-		configFile := os.Args[2]
+		configFile := args[1]
 		if err := listPendingTickets(configFile); err != nil {
-			fmt.Fprintf(os.Stderr, "❌ Error listing tickets: %v\n", err)
-			os.Exit(1)
+			errorf("❌ Error listing tickets: %v\n", err)
+			os.Exit(ExitError)
+		}
+		os.Exit(0)
+	}
+
+	// Check for only flag (run one workflow ad hoc; only self-improve needs a PRD)
+	if args[0] == "--only" {
+		if len(args) < 2 {
+			fmt.Fprintf(os.Stderr, "Usage: %s --only <%s>\n", os.Args[0], strings.Join(OnlyWorkflows, "|"))
+			os.Exit(ExitConfig)
+		}
+		if err := runOnlyWorkflow(args[1]); err != nil {
+			errorf("❌ Error: %v\n", err)
+			os.Exit(exitCode(false, err))
+		}
+		os.Exit(0)
+	}
+
+	// Check for review-only flag (analysis and report only; no planning, implementation or commits)
+	if args[0] == "--review-only" {
+		if err := runReview(); err != nil {
+			errorf("❌ Error: %v\n", err)
+			os.Exit(exitCode(false, err))
 		}
 		os.Exit(0)
 	}
 
+	// Check for criteria flag (what's left, per task, from the PRD's checkboxes rather than Claude's summary)
+	if args[0] == "--criteria" {
+		if _, err := printCriteriaReport(); err != nil {
+			errorf("❌ Error: %v\n", err)
+			os.Exit(ExitError)
+		}
+		os.Exit(0)
+	}
+
+	// Check for show flag (audit view of an iteration's or a ticket's commits)
+	if args[0] == "--show" {
+		if len(args) < 2 {
+			fmt.Fprintf(os.Stderr, "Usage: %s --show <iteration|ticket>\n", os.Args[0])
+			os.Exit(ExitConfig)
+		}
+		if err := showIteration(args[1]); err != nil {
+			errorf("❌ Error: %v\n", err)
+			os.Exit(ExitError)
+		}
+		os.Exit(0)
+	}
+
+	// Check for clean flag (inverse of --init: remove state and run artifacts)
+	if args[0] == "--clean" {
+		all, yes := false, false
+		for _, arg := range args[1:] {
+			switch arg {
+			case "--all":
+				all = true
+			case "--yes", "-y":
+				yes = true
+			default:
+				errorf("Error: unknown --clean option %q (expected --all and/or --yes)\n", arg)
+				os.Exit(ExitConfig)
+			}
+		}
+		if err := cleanRalphState(all, yes); err != nil {
+			errorf("❌ Error: %v\n", err)
+			os.Exit(ExitError)
+		}
+		os.Exit(0)
+	}
+
+	// Check for migrate-state flag (upgrade a legacy state file in place)
+	if args[0] == "--migrate-state" {
+		dryRun := len(args) > 1 && args[1] == "--dry-run"
+		if err := migrateState(dryRun); err != nil {
+			errorf("❌ Error: %v\n", err)
+			os.Exit(ExitError)
+		}
+		os.Exit(0)
+	}
+
+	// Check for manual resume override (escape hatch when automatic resume picks the wrong point)
+	if args[0] == "--resume-iteration" || args[0] == "--resume-step" {
+		resumeIteration, resumeStep := 0, 0
+		for i := 0; i+1 < len(args); i += 2 {
+			var value int
+			if _, err := fmt.Sscanf(args[i+1], "%d", &value); err != nil {
+				errorf("Error: invalid value for %s: %s\n", args[i], args[i+1])
+				os.Exit(ExitConfig)
+			}
+			switch args[i] {
+			case "--resume-iteration":
+				resumeIteration = value
+			case "--resume-step":
+				resumeStep = value
+			default:
+				errorf("Error: unexpected argument: %s\n", args[i])
+				os.Exit(ExitConfig)
+			}
+		}
+		if resumeIteration == 0 || resumeStep == 0 {
+			fmt.Fprintf(os.Stderr, "Usage: %s --resume-iteration <N> --resume-step <S>\n", os.Args[0])
+			fmt.Fprintf(os.Stderr, "  N: Iteration to resume at (1 to the saved max iterations)\n")
+			fmt.Fprintf(os.Stderr, "  S: 1 = Workflow 1, 2 = Workflow 2, 3 = task check after Workflow 2\n")
+			os.Exit(ExitConfig)
+		}
+
+		state, err := applyResumeOverride(resumeIteration, resumeStep)
+		if err != nil {
+			errorf("❌ Error: %v\n", err)
+			os.Exit(ExitConfig)
+		}
+
+		completed, err := executeRalphWorkflowFrom(state.Iteration, resumeStep, state.MaxIterations, nil)
+		exitRun(completed, err, state.MaxIterations)
+	}
+
+	// Check for continue flag (extend the previous run's iteration budget)
+	if args[0] == "--continue" {
+		if len(args) < 2 {
+			fmt.Fprintf(os.Stderr, "Usage: %s --continue <iterations>\n", os.Args[0])
+			fmt.Fprintf(os.Stderr, "  iterations:  Number of additional iterations to run (must be >= 1)\n")
+			os.Exit(ExitConfig)
+		}
+
+		var extra int
+		if _, err := fmt.Sscanf(args[1], "%d", &extra); err != nil || extra < 1 {
+			errorf("Error: invalid iterations value: %s (must be >= 1)\n", args[1])
+			os.Exit(ExitConfig)
+		}
+
+		completed, maxIterations, err := continueRun(extra)
+		exitRun(completed, err, maxIterations)
+	}
+
 	var maxIterations int
-	if _, err := fmt.Sscanf(os.Args[1], "%d", &maxIterations); err != nil || maxIterations < 1 {
-		fmt.Fprintf(os.Stderr, "Error: invalid iterations value: %s\n", os.Args[1])
-		os.Exit(1)
+	if _, err := fmt.Sscanf(args[0], "%d", &maxIterations); err != nil || maxIterations < 1 {
+		errorf("Error: invalid iterations value: %s\n", args[0])
+		os.Exit(ExitConfig)
 	}
 
 	// Use current working directory (where the command is run from)
 	// This matches the bash script behavior of using the script's directory
 	scriptDir, err := os.Getwd()
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: failed to get current directory: %v\n", err)
-		os.Exit(1)
+		errorf("Error: failed to get current directory: %v\n", err)
+		os.Exit(ExitError)
 	}
 
 	// Verify required files exist
-	for _, filename := range RequiredFiles {
-		if _, err := os.Stat(filename); os.IsNotExist(err) {
-			fmt.Fprintf(os.Stderr, "❌ Error: %s not found in %s\n", filename, scriptDir)
-			os.Exit(1)
+	for _, filename := range requiredFiles(ModeLoop) {
+		if !requiredFileExists(filename) {
+			errorf("❌ Error: %s (or %s/*.md) not found in %s\n", filename, PRDDir, scriptDir)
+			os.Exit(ExitConfig)
 		}
 	}
 
-	// Use shared loop function
-	completed, err := executeRalphWorkflow(maxIterations, nil)
-	if err != nil {
-		// Error already printed in steps.go with step context
-		os.Exit(1)
+	// Don't run Claude against a PRD with nothing to do
+	if err := checkPRDActionable(); err != nil {
+		errorf("❌ Error: %v\n", err)
+		os.Exit(ExitConfig)
 	}
 
-	if completed {
-		fmt.Println("✅ PRD completed successfully!")
-		os.Exit(0)
-	} else {
-		fmt.Printf("⚠️  Reached iteration limit (%d) but PRD not yet complete\n", maxIterations)
-		os.Exit(1)
+	// Optional one-time simplification pass before a fresh run. ralph <iterations> always starts fresh at
+	// iteration 1; resuming (--continue, --resume-iteration) returns above. A state file left by an earlier
+	// run (kept at the iteration limit) doesn't make this a resume, so it must not skip the pass.
+	if ralphConfig.SimplifyBeforeLoop {
+		if err := reprocessPRD(); err != nil {
+			statusf("⚠️  Pre-loop PRD simplification skipped: %v\n", err)
+		}
 	}
+
+	// Use shared loop function
+	completed, err := executeRalphWorkflow(maxIterations, nil)
+	exitRun(completed, err, maxIterations)
 }
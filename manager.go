@@ -3,14 +3,18 @@ package main
 import (
 	"bufio"
 	"bytes"
+	"crypto/rand"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"math"
 	"net/http"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
 	"time"
 
@@ -23,33 +27,119 @@ type LinearConfig struct {
 	Project      string `toml:"project"`       // Project ID to filter tickets
 	EscalateUser string `toml:"escalate_user"`
 	BaseBranch   string `toml:"base_branch"`  // Base branch to create feature branches from (defaults to "main" or "master")
+
+	Tracker string `toml:"tracker"` // Issue tracker: "linear" (default) or "jira"
+
+	// Jira (tracker = "jira"); project is the Jira project key and escalate_user an Atlassian account ID
+	JiraURL      string            `toml:"jira_url"`      // Site URL, e.g. https://yourcompany.atlassian.net
+	JiraEmail    string            `toml:"jira_email"`    // Account e-mail used with the API token
+	JiraToken    string            `toml:"jira_token"`    // API token; falls back to JIRA_API_TOKEN
+	JiraJQL      string            `toml:"jira_jql"`      // Overrides the query for Todo tickets
+	JiraStatuses map[string]string `toml:"jira_statuses"` // Ralph state ("Todo", "In Progress", "Done") -> Jira status name
+
+	// Bitbucket credentials (only needed when origin is a bitbucket.org remote).
+	// Fall back to BITBUCKET_TOKEN / BITBUCKET_USERNAME environment variables.
+	BitbucketToken    string `toml:"bitbucket_token"`
+	BitbucketUsername string `toml:"bitbucket_username"` // Set when using an app password instead of an access token
+
+	EscalateTeamless bool `toml:"escalate_teamless"` // Comment (tagging escalate_user) on Todo tickets skipped for having no team
+
+	EscalatedLabel string `toml:"escalated_label"` // Label added when a ticket is escalated back to Todo (default "escalated")
+
+	// Ticket selection (Linear)
+	TicketOrder   string `toml:"ticket_order"`    // "priority" (default) or "board" (Linear's manual board order)
+	WaitForParent bool   `toml:"wait_for_parent"` // Skip sub-issues until their parent issue is Done
+
+	PostDiffstat bool `toml:"post_diffstat"` // Include a diffstat summary (base...branch) in the completion comment
+
+	SingleProgressComment bool `toml:"single_progress_comment"` // Update one progress comment per ticket instead of posting one per iteration
+
+	// Human checkpoint between planning and implementation
+	RequirePlanApproval bool   `toml:"require_plan_approval"` // Post each plan and wait for the approval label before implementing
+	PlanApprovalLabel   string `toml:"plan_approval_label"`   // Label that approves the plan (default "approved")
+	PlanApprovalTimeout int    `toml:"plan_approval_timeout"` // Minutes to wait for approval before escalating (default 60)
+
+	// Pre-PR merge conflict check against the (freshly fetched) base branch
+	CheckConflicts bool `toml:"check_conflicts"` // Escalate instead of opening a pull request that would conflict
+	AutoMergeBase  bool `toml:"auto_merge_base"` // On conflict, first try merging the base branch into the ticket branch
+
+	// Iteration budgets (0 = not set)
+	MaxIterationsPerTicket     int `toml:"max_iterations_per_ticket"`     // Per-ticket budget, overrides the command-line iterations
+	IterationsPerEstimatePoint int `toml:"iterations_per_estimate_point"` // Derive the per-ticket budget from the ticket estimate
+	MaxTotalIterations         int `toml:"max_total_iterations"`          // Cap on iterations across the whole manager session
+
+	MaxConsecutiveFailures int `toml:"max_consecutive_failures"` // --keep-going stops after this many failed tickets in a row (default 3)
+
+	// Batching related tickets into one branch and pull request
+	BatchBy      string `toml:"batch_by"`       // "parent" (sub-issues of the same parent) or "label" (same "batch:<name>" label); empty disables batching
+	MaxBatchSize int    `toml:"max_batch_size"` // Most tickets worked in one batch, including the selected one (default 5)
+
+	OnEmpty string `toml:"on_empty"` // No Todo ticket to work on: "wait" (default), "exit", or "exit-after N" empty polls
+
+	// Run artifacts commented on the pull request for reviewers
+	PRArtifacts         []string `toml:"pr_artifacts"`           // "progress" (final PROGRESS.md) and/or "test_report" (test_command output)
+	PRArtifactsMaxBytes int      `toml:"pr_artifacts_max_bytes"` // Size bound for the artifacts comment (default 20000)
+
+	QueuePreview int `toml:"queue_preview"` // Upcoming Todo tickets listed at session start and after each completed ticket (default 3; 0 = off)
+
+	BranchTemplate string `toml:"branch_template"` // Ticket branch scheme with {{prefix}}, {{identifier}}, {{id}}, {{slug}} (default "{{prefix}}/{{identifier}}-{{slug}}")
 }
 
+// DefaultQueuePreview is how many upcoming tickets manager mode lists when queue_preview is not set
+const DefaultQueuePreview = 3
+
+// DefaultMaxConsecutiveFailures is the --keep-going circuit breaker threshold when max_consecutive_failures is not set
+const DefaultMaxConsecutiveFailures = 3
+
 // ManagerState represents the resume state for manager mode
 type ManagerState struct {
-	IssueID    string
-	BranchName string
-	Iteration  int
+	IssueID           string
+	BranchName        string
+	Iteration         int
+	ProgressCommentID string   // Comment updated with progress when single_progress_comment is set
+	BatchIssueIDs     []string // Tickets batched with IssueID on the same branch (batch_by)
+	Phase             string   // How far the ticket got (ManagerPhase*); empty means working
+	PRURL             string   // Pull request opened for the ticket (phase pr-created)
+	ArtifactsPosted   bool     // Run artifacts (pr_artifacts) were commented on PRURL
 }
 
+// Manager state phases, so a resume after a crash skips work that already finished
+const (
+	ManagerPhaseWorking   = "working"              // Ralph loop running (or not started)
+	ManagerPhaseCompleted = "completed-pending-pr" // Loop completed; pull request not opened yet
+	ManagerPhasePRCreated = "pr-created"           // Pull request opened; ticket not moved to Done yet
+)
+
 // LinearClient handles Linear API interactions
 type LinearClient struct {
 	Token   string
 	BaseURL string
+
+	workspaceKey string // Cached organization URL key for profile-URL mentions
 }
 
-// LinearIssue represents a Linear issue/ticket
-type LinearIssue struct {
+// Issue represents a tracker issue/ticket. The shape follows Linear's API; other providers (Jira) map into it.
+type Issue struct {
 	ID          string
 	Identifier  string
 	Title       string
 	Description string
 	Priority    float64
+	SortOrder   float64 // Position on the Linear board (manual ordering; lower is higher up)
 	Estimate    *float64
 	State       struct {
 		Name string
 		ID   string
 	}
+	Parent *struct {
+		ID         string
+		Identifier string
+		Title      string
+		State      struct {
+			Name string
+			Type string // Linear workflow state type: backlog, unstarted, started, completed, canceled
+		}
+	}
 	Team struct {
 		ID   string
 		Name string
@@ -90,34 +180,195 @@ type LinearUser struct {
 // GraphQLResponse represents a generic GraphQL response
 type GraphQLResponse struct {
 	Data   json.RawMessage `json:"data"`
-	Errors []struct {
-		Message string `json:"message"`
-	} `json:"errors"`
+	Errors []GraphQLError  `json:"errors"`
+}
+
+// GraphQLError is one entry of a GraphQL response's errors array
+type GraphQLError struct {
+	Message    string `json:"message"`
+	Extensions struct {
+		Code string `json:"code"`
+	} `json:"extensions"`
+}
+
+// fatalGraphQLCodes are the error codes (extensions.code) that fail a request even when it returned data
+var fatalGraphQLCodes = []string{
+	"AUTHENTICATION_ERROR", "UNAUTHENTICATED", "FORBIDDEN", "ENTITY_NOT_FOUND", "NOT_FOUND",
+	"INVALID_INPUT", "GRAPHQL_VALIDATION_FAILED", "RATELIMITED", "INTERNAL_SERVER_ERROR",
+}
+
+// fatalGraphQLMessages are message fragments (lowercase) that mark an error as fatal when it has no known code
+var fatalGraphQLMessages = []string{"authentication", "unauthorized", "not authorized", "forbidden", "not found", "access denied"}
+
+// isFatalGraphQLError reports whether an error entry means the request failed, as opposed to a non-fatal
+// error returned alongside usable data (e.g. a deprecated-field warning)
+func isFatalGraphQLError(e GraphQLError) bool {
+	for _, code := range fatalGraphQLCodes {
+		if strings.EqualFold(e.Extensions.Code, code) {
+			return true
+		}
+	}
+	message := strings.ToLower(e.Message)
+	for _, fragment := range fatalGraphQLMessages {
+		if strings.Contains(message, fragment) {
+			return true
+		}
+	}
+	return false
 }
 
-// loadLinearConfig loads and parses the Linear config TOML file
+// hasGraphQLData reports whether a response carried data (not missing or null)
+func hasGraphQLData(data json.RawMessage) bool {
+	trimmed := strings.TrimSpace(string(data))
+	return trimmed != "" && trimmed != "null"
+}
+
+// loadLinearConfig loads and parses the Linear config TOML file.
+// Unknown keys and all missing/invalid fields are reported together so the user can fix them in one pass.
 func loadLinearConfig(filename string) (*LinearConfig, error) {
 	data, err := os.ReadFile(filename)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read config file: %v", err)
 	}
 
-	var config LinearConfig
-	if err := toml.Unmarshal(data, &config); err != nil {
+	config := LinearConfig{QueuePreview: DefaultQueuePreview}
+	decoder := toml.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&config); err != nil {
+		var strictErr *toml.StrictMissingError
+		if errors.As(err, &strictErr) {
+			var unknown []string
+			for _, e := range strictErr.Errors {
+				row, _ := e.Position()
+				unknown = append(unknown, fmt.Sprintf("  - unknown key %q (line %d)", strings.Join(e.Key(), "."), row))
+			}
+			return nil, fmt.Errorf("invalid config file %s (check for typos):\n%s", filename, strings.Join(unknown, "\n"))
+		}
 		return nil, fmt.Errorf("failed to parse config file: %v", err)
 	}
 
-	if config.Token == "" {
-		return nil, fmt.Errorf("token is required in config file")
+	if problems := validateLinearConfig(&config); len(problems) > 0 {
+		return nil, fmt.Errorf("invalid config file %s:\n  - %s", filename, strings.Join(problems, "\n  - "))
+	}
+
+	return &config, nil
+}
+
+// uuidPattern matches a Linear entity UUID
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// validateLinearConfig checks required fields and value ranges, returning one message per problem
+func validateLinearConfig(config *LinearConfig) []string {
+	var problems []string
+	switch strings.ToLower(config.Tracker) {
+	case "", TrackerLinear:
+		if config.Token == "" {
+			problems = append(problems, "token is required (get an API key from https://linear.app/settings/api)")
+		}
+		if config.Project == "" {
+			problems = append(problems, "project is required (project UUID; run --tickets to list projects)")
+		}
+		if config.EscalateUser == "" {
+			problems = append(problems, "escalate_user is required (Linear username to tag on errors)")
+		}
+	case TrackerJira:
+		if config.JiraURL == "" {
+			problems = append(problems, "jira_url is required (e.g. https://yourcompany.atlassian.net)")
+		}
+		if config.JiraEmail == "" {
+			problems = append(problems, "jira_email is required (account e-mail for the API token)")
+		}
+		if config.JiraToken == "" && os.Getenv("JIRA_API_TOKEN") == "" {
+			problems = append(problems, "jira_token is required (or set JIRA_API_TOKEN; create one at https://id.atlassian.com/manage-profile/security/api-tokens)")
+		}
+		if config.Project == "" && config.JiraJQL == "" {
+			problems = append(problems, "project is required (Jira project key, e.g. PROJ) unless jira_jql is set")
+		}
+		if config.EscalateUser == "" {
+			problems = append(problems, "escalate_user is required (Atlassian account ID to mention on errors)")
+		}
+	default:
+		problems = append(problems, fmt.Sprintf("tracker must be %q or %q, got %q", TrackerLinear, TrackerJira, config.Tracker))
+	}
+	switch strings.ToLower(config.TicketOrder) {
+	case "", TicketOrderPriority, TicketOrderBoard:
+	default:
+		problems = append(problems, fmt.Sprintf("ticket_order must be %q or %q, got %q", TicketOrderPriority, TicketOrderBoard, config.TicketOrder))
+	}
+	if config.MaxIterationsPerTicket < 0 {
+		problems = append(problems, "max_iterations_per_ticket must be >= 0")
+	}
+	if config.QueuePreview < 0 {
+		problems = append(problems, "queue_preview must be >= 0")
+	}
+	for _, artifact := range config.PRArtifacts {
+		if artifact != PRArtifactProgress && artifact != PRArtifactTestReport {
+			problems = append(problems, fmt.Sprintf("pr_artifacts entries must be %q or %q, got %q", PRArtifactProgress, PRArtifactTestReport, artifact))
+		}
+	}
+	if config.PRArtifactsMaxBytes < 0 {
+		problems = append(problems, "pr_artifacts_max_bytes must be >= 0")
+	}
+	if config.BranchTemplate != "" {
+		if problem := validateBranchTemplate(config.BranchTemplate); problem != "" {
+			problems = append(problems, problem)
+		}
+	}
+	if config.IterationsPerEstimatePoint < 0 {
+		problems = append(problems, "iterations_per_estimate_point must be >= 0")
+	}
+	if config.MaxTotalIterations < 0 {
+		problems = append(problems, "max_total_iterations must be >= 0")
+	}
+	if config.PlanApprovalTimeout < 0 {
+		problems = append(problems, "plan_approval_timeout must be >= 0")
+	}
+	if config.MaxConsecutiveFailures < 0 {
+		problems = append(problems, "max_consecutive_failures must be >= 0")
+	}
+	switch strings.ToLower(config.BatchBy) {
+	case "", BatchByParent, BatchByLabel:
+	default:
+		problems = append(problems, fmt.Sprintf("batch_by must be %q or %q, got %q", BatchByParent, BatchByLabel, config.BatchBy))
+	}
+	if config.MaxBatchSize < 0 {
+		problems = append(problems, "max_batch_size must be >= 0")
+	}
+	if _, err := parseOnEmpty(config.OnEmpty); err != nil {
+		problems = append(problems, err.Error())
+	}
+	return problems
+}
+
+// resolveLinearProject ensures config.Project is a project UUID. When a slug or name was configured,
+// it is resolved via listProjects; if that fails, the available projects and their UUIDs are listed in the error.
+func resolveLinearProject(client *LinearClient, config *LinearConfig) error {
+	if uuidPattern.MatchString(config.Project) {
+		return nil
 	}
-	if config.Project == "" {
-		return nil, fmt.Errorf("project is required in config file")
+
+	projects, err := client.listProjects()
+	if err != nil {
+		return fmt.Errorf("project %q is not a UUID and projects could not be listed to resolve it: %v", config.Project, err)
 	}
-	if config.EscalateUser == "" {
-		return nil, fmt.Errorf("escalate_user is required in config file")
+
+	for _, project := range projects {
+		if strings.EqualFold(project.SlugID, config.Project) || strings.EqualFold(project.Name, config.Project) ||
+			strings.HasSuffix(strings.ToLower(config.Project), "-"+strings.ToLower(project.SlugID)) {
+			statusf("ℹ️  Resolved project %q to %s (%s). Set project = \"%s\" in the config to skip this lookup.\n", config.Project, project.Name, project.ID, project.ID)
+			config.Project = project.ID
+			return nil
+		}
 	}
 
-	return &config, nil
+	var available []string
+	for _, project := range projects {
+		available = append(available, fmt.Sprintf("  - %s (Slug: %s, ID: %s)", project.Name, project.SlugID, project.ID))
+	}
+	if len(available) == 0 {
+		return fmt.Errorf("project %q is not a UUID and no projects were found in the workspace", config.Project)
+	}
+	return fmt.Errorf("project %q is not a UUID and does not match any project slug or name. Use one of these project IDs:\n%s", config.Project, strings.Join(available, "\n"))
 }
 
 // NewLinearClient creates a new Linear API client
@@ -128,6 +379,16 @@ func NewLinearClient(token string) *LinearClient {
 	}
 }
 
+// Name returns the tracker name
+func (c *LinearClient) Name() string {
+	return "Linear"
+}
+
+// BranchPrefix returns the prefix of ticket branches (linear/ENG-123-{slug} by default)
+func (c *LinearClient) BranchPrefix() string {
+	return "linear"
+}
+
 // executeGraphQL executes a GraphQL query/mutation
 func (c *LinearClient) executeGraphQL(query string, variables map[string]interface{}) (json.RawMessage, error) {
 	payload := map[string]interface{}{
@@ -170,18 +431,26 @@ func (c *LinearClient) executeGraphQL(query string, variables map[string]interfa
 
 	if len(graphqlResp.Errors) > 0 {
 		var errorMsgs []string
+		fatal := !hasGraphQLData(graphqlResp.Data)
 		for _, e := range graphqlResp.Errors {
 			errorMsgs = append(errorMsgs, e.Message)
+			if isFatalGraphQLError(e) {
+				fatal = true
+			}
+		}
+		if fatal {
+			return nil, fmt.Errorf("GraphQL errors: %s", strings.Join(errorMsgs, "; "))
 		}
-		return nil, fmt.Errorf("GraphQL errors: %s", strings.Join(errorMsgs, "; "))
+		// Partial success: keep the data rather than discarding a query that mostly worked
+		statusf("⚠️  Warning: Linear returned data with non-fatal GraphQL errors: %s\n", strings.Join(errorMsgs, "; "))
 	}
 
 	return graphqlResp.Data, nil
 }
 
-// fetchTodoTickets fetches tickets in "Todo" state, ordered by priority
+// fetchTodoTickets fetches tickets in "Todo" state, ordered by priority (see orderTickets for board order)
 // Filters by projectID (must be project UUID, not slug)
-func (c *LinearClient) fetchTodoTickets(projectID string) ([]LinearIssue, error) {
+func (c *LinearClient) fetchTodoTickets(projectID string) ([]Issue, error) {
 	query := `
 		query($projectId: ID!) {
 			issues(
@@ -196,7 +465,17 @@ func (c *LinearClient) fetchTodoTickets(projectID string) ([]LinearIssue, error)
 					title
 					description
 					priority
+					sortOrder
 					estimate
+					parent {
+						id
+						identifier
+						title
+						state {
+							name
+							type
+						}
+					}
 					state {
 						name
 						id
@@ -244,7 +523,7 @@ func (c *LinearClient) fetchTodoTickets(projectID string) ([]LinearIssue, error)
 
 	var result struct {
 		Issues struct {
-			Nodes []LinearIssue `json:"nodes"`
+			Nodes []Issue `json:"nodes"`
 		} `json:"issues"`
 	}
 
@@ -332,6 +611,115 @@ func (c *LinearClient) updateTicketStatus(issueID, teamID, stateName string) err
 	return err
 }
 
+// findOrCreateLabel returns the ID of the label with the given name (workspace or team label), creating a team label if needed
+func (c *LinearClient) findOrCreateLabel(teamID, name string) (string, error) {
+	query := `
+		query($name: String!) {
+			issueLabels(filter: { name: { eq: $name } }) {
+				nodes {
+					id
+					name
+					team {
+						id
+					}
+				}
+			}
+		}
+	`
+
+	data, err := c.executeGraphQL(query, map[string]interface{}{"name": name})
+	if err != nil {
+		return "", err
+	}
+
+	var result struct {
+		IssueLabels struct {
+			Nodes []struct {
+				ID   string `json:"id"`
+				Name string `json:"name"`
+				Team *struct {
+					ID string `json:"id"`
+				} `json:"team"`
+			} `json:"nodes"`
+		} `json:"issueLabels"`
+	}
+
+	if err := json.Unmarshal(data, &result); err != nil {
+		return "", fmt.Errorf("failed to parse labels: %v", err)
+	}
+
+	for _, label := range result.IssueLabels.Nodes {
+		if label.Team == nil || label.Team.ID == teamID {
+			return label.ID, nil
+		}
+	}
+
+	mutation := `
+		mutation($name: String!, $teamId: String!) {
+			issueLabelCreate(input: { name: $name, teamId: $teamId }) {
+				success
+				issueLabel {
+					id
+				}
+			}
+		}
+	`
+
+	data, err = c.executeGraphQL(mutation, map[string]interface{}{"name": name, "teamId": teamID})
+	if err != nil {
+		return "", fmt.Errorf("failed to create label %s: %v", name, err)
+	}
+
+	var created struct {
+		IssueLabelCreate struct {
+			Success    bool `json:"success"`
+			IssueLabel struct {
+				ID string `json:"id"`
+			} `json:"issueLabel"`
+		} `json:"issueLabelCreate"`
+	}
+
+	if err := json.Unmarshal(data, &created); err != nil {
+		return "", fmt.Errorf("failed to parse label creation: %v", err)
+	}
+	if !created.IssueLabelCreate.Success || created.IssueLabelCreate.IssueLabel.ID == "" {
+		return "", fmt.Errorf("failed to create label %s", name)
+	}
+
+	return created.IssueLabelCreate.IssueLabel.ID, nil
+}
+
+// addIssueLabel adds a label to a ticket
+func (c *LinearClient) addIssueLabel(issueID, labelID string) error {
+	mutation := `
+		mutation($issueId: String!, $labelId: String!) {
+			issueAddLabel(id: $issueId, labelId: $labelId) {
+				success
+			}
+		}
+	`
+
+	variables := map[string]interface{}{
+		"issueId": issueID,
+		"labelId": labelID,
+	}
+
+	_, err := c.executeGraphQL(mutation, variables)
+	return err
+}
+
+// addLabel adds the named label to a ticket, creating it as a team label if needed
+func (c *LinearClient) addLabel(issue *Issue, name string) error {
+	labelID, err := c.findOrCreateLabel(issue.Team.ID, name)
+	if err != nil {
+		return fmt.Errorf("failed to find or create label %s: %v", name, err)
+	}
+	if err := c.addIssueLabel(issue.ID, labelID); err != nil {
+		return fmt.Errorf("failed to add label %s: %v", name, err)
+	}
+	return nil
+}
+
 // findUserByUsername finds a user by their display name (username)
 func (c *LinearClient) findUserByUsername(username string) (*LinearUser, error) {
 	query := `
@@ -401,16 +789,46 @@ func (c *LinearClient) getWorkspaceInfo() (string, error) {
 	return result.Organization.URLKey, nil
 }
 
+// verifyUser checks that username is a Linear display name and caches the workspace URL key used to mention them
+func (c *LinearClient) verifyUser(username string) error {
+	if _, err := c.findUserByUsername(username); err != nil {
+		return err
+	}
+	if _, err := c.workspaceURLKey(); err != nil {
+		return fmt.Errorf("could not get workspace info for mentions: %v", err)
+	}
+	return nil
+}
+
+// workspaceURLKey returns the organization URL key, fetching it once and caching it on the client
+func (c *LinearClient) workspaceURLKey() (string, error) {
+	if c.workspaceKey != "" {
+		return c.workspaceKey, nil
+	}
+	key, err := c.getWorkspaceInfo()
+	if err != nil {
+		return "", err
+	}
+	c.workspaceKey = key
+	return key, nil
+}
+
 // addTicketComment adds a comment to a ticket and optionally tags users
 // Note: Linear uses profile URLs for mentions: https://linear.app/{workspace}/profiles/{username}
 func (c *LinearClient) addTicketComment(issueID, comment string, usernames []string) error {
+	_, err := c.createTicketComment(issueID, comment, usernames)
+	return err
+}
+
+// createTicketComment adds a comment to a ticket (tagging usernames) and returns the new comment's ID
+func (c *LinearClient) createTicketComment(issueID, comment string, usernames []string) (string, error) {
 	commentBody := comment
 	if len(usernames) > 0 {
-		// Get workspace URL key for constructing profile URLs
-		workspaceKey, err := c.getWorkspaceInfo()
+		// Get workspace URL key for constructing profile URLs (cached after the first lookup)
+		workspaceKey, err := c.workspaceURLKey()
 		if err != nil {
-			// If we can't get workspace, just use @mentions as fallback
-			fmt.Printf("⚠️  Warning: Could not get workspace info for mentions: %v\n", err)
+			// If we can't get workspace, just use @mentions as fallback; Linear does not resolve these, so nobody is notified
+			statusf("⚠️  Warning: Could not get workspace info for mentions: %v (plain @mentions will not notify %s)\n", err, strings.Join(usernames, ", "))
 			mentions := []string{}
 			for _, username := range usernames {
 				mentions = append(mentions, "@"+username)
@@ -447,10 +865,147 @@ func (c *LinearClient) addTicketComment(issueID, comment string, usernames []str
 		"body":    commentBody,
 	}
 
+	data, err := c.executeGraphQL(mutation, variables)
+	if err != nil {
+		return "", err
+	}
+
+	var result struct {
+		CommentCreate struct {
+			Comment struct {
+				ID string `json:"id"`
+			} `json:"comment"`
+		} `json:"commentCreate"`
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return "", fmt.Errorf("failed to parse comment: %v", err)
+	}
+	return result.CommentCreate.Comment.ID, nil
+}
+
+// getCommentBody returns the current body of a comment
+func (c *LinearClient) getCommentBody(issueID, commentID string) (string, error) {
+	query := `
+		query($commentId: String!) {
+			comment(id: $commentId) {
+				body
+			}
+		}
+	`
+
+	variables := map[string]interface{}{
+		"commentId": commentID,
+	}
+
+	data, err := c.executeGraphQL(query, variables)
+	if err != nil {
+		return "", err
+	}
+
+	var result struct {
+		Comment struct {
+			Body string `json:"body"`
+		} `json:"comment"`
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return "", fmt.Errorf("failed to parse comment: %v", err)
+	}
+	return result.Comment.Body, nil
+}
+
+// listTicketComments returns the issue's comments, oldest first
+func (c *LinearClient) listTicketComments(issueID string) ([]TicketComment, error) {
+	query := `
+		query($issueId: String!) {
+			issue(id: $issueId) {
+				comments(last: 100) {
+					nodes {
+						id
+						body
+						createdAt
+					}
+				}
+			}
+		}
+	`
+
+	variables := map[string]interface{}{
+		"issueId": issueID,
+	}
+
+	data, err := c.executeGraphQL(query, variables)
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Issue struct {
+			Comments struct {
+				Nodes []struct {
+					ID        string    `json:"id"`
+					Body      string    `json:"body"`
+					CreatedAt time.Time `json:"createdAt"`
+				} `json:"nodes"`
+			} `json:"comments"`
+		} `json:"issue"`
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse comments: %v", err)
+	}
+
+	var comments []TicketComment
+	for _, node := range result.Issue.Comments.Nodes {
+		comments = append(comments, TicketComment{ID: node.ID, Body: node.Body, CreatedAt: node.CreatedAt})
+	}
+	sortTicketComments(comments)
+	return comments, nil
+}
+
+// updateTicketComment replaces the body of an existing comment
+func (c *LinearClient) updateTicketComment(issueID, commentID, body string) error {
+	mutation := `
+		mutation($commentId: String!, $body: String!) {
+			commentUpdate(id: $commentId, input: { body: $body }) {
+				success
+			}
+		}
+	`
+
+	variables := map[string]interface{}{
+		"commentId": commentID,
+		"body":      body,
+	}
+
 	_, err := c.executeGraphQL(mutation, variables)
 	return err
 }
 
+// postProgressComment keeps one progress comment per ticket, appending each iteration's section to it.
+// The comment ID lives in the manager state so a resumed ticket keeps updating the same comment;
+// if the update fails, a new progress comment is started.
+func postProgressComment(client IssueProvider, issueID string, state *ManagerState, section string) error {
+	if state.ProgressCommentID != "" {
+		body, err := client.getCommentBody(issueID, state.ProgressCommentID)
+		if err == nil {
+			err = client.updateTicketComment(issueID, state.ProgressCommentID, body+"\n\n---\n\n"+section)
+		}
+		if err == nil {
+			return nil
+		}
+		statusf("⚠️  Warning: failed to update progress comment, posting a new one: %v\n", err)
+	}
+
+	commentID, err := client.createTicketComment(issueID, "**Ralph progress**\n\n"+section, nil)
+	if err != nil {
+		return err
+	}
+	state.ProgressCommentID = commentID
+	if err := saveManagerState(state); err != nil {
+		statusf("⚠️  Warning: failed to save manager state: %v\n", err)
+	}
+	return nil
+}
+
 // slugify converts a string to a URL-friendly slug
 func slugify(s string) string {
 	// Convert to lowercase
@@ -475,13 +1030,11 @@ func createGitBranch(branchName string, baseBranch string) error {
 	if baseBranch == "" {
 		// Try to detect default branch (main or master)
 		// Check if main exists
-		checkMain := exec.Command("git", "show-ref", "--verify", "--quiet", "refs/heads/main")
-		if err := checkMain.Run(); err == nil {
+		if err := gitRun("show-ref", "--verify", "--quiet", "refs/heads/main"); err == nil {
 			baseBranch = "main"
 		} else {
 			// Check if master exists
-			checkMaster := exec.Command("git", "show-ref", "--verify", "--quiet", "refs/heads/master")
-			if err := checkMaster.Run(); err == nil {
+			if err := gitRun("show-ref", "--verify", "--quiet", "refs/heads/master"); err == nil {
 				baseBranch = "master"
 			} else {
 				return fmt.Errorf("failed to determine base branch (tried 'main' and 'master'): neither branch exists")
@@ -490,78 +1043,455 @@ func createGitBranch(branchName string, baseBranch string) error {
 	}
 
 	// Ensure we're on the base branch first
-	cmd := exec.Command("git", "rev-parse", "--abbrev-ref", "HEAD")
-	currentBranch, err := cmd.Output()
+	currentBranch, err := gitOutput("rev-parse", "--abbrev-ref", "HEAD")
 	if err != nil {
 		return fmt.Errorf("failed to get current branch: %v", err)
 	}
 
 	branchStr := strings.TrimSpace(string(currentBranch))
-	if branchStr != baseBranch {
+	if branchStr == branchName {
+		// Already on the ticket branch (e.g. resuming)
+		return nil
+	}
+
+	// Uncommitted changes to tracked files would make checkout fail or carry over into the ticket branch
+	if err := checkCleanWorkingTree(); err != nil {
+		return err
+	}
+
+	if branchStr != baseBranch {
 		// Try to checkout the base branch
-		checkoutBase := exec.Command("git", "checkout", baseBranch)
-		if err := checkoutBase.Run(); err != nil {
+		if err := runGitWithRetry("checkout", baseBranch); err != nil {
 			return fmt.Errorf("not on %s and failed to checkout: %v", baseBranch, err)
 		}
 	}
 
+	// Branch might already exist (e.g. a resumed ticket), in which case just check it out
+	if err := gitRun("show-ref", "--verify", "--quiet", "refs/heads/"+branchName); err == nil {
+		if err := runGitWithRetry("checkout", branchName); err != nil {
+			return fmt.Errorf("failed to checkout existing branch %s: %v", branchName, err)
+		}
+		return nil
+	}
+
 	// Create and checkout new branch
-	cmd = exec.Command("git", "checkout", "-b", branchName)
-	if err := cmd.Run(); err != nil {
-		// Branch might already exist, try to checkout
-		cmd = exec.Command("git", "checkout", branchName)
-		if err := cmd.Run(); err != nil {
-			return fmt.Errorf("failed to create/checkout branch: %v", err)
+	if err := runGitWithRetry("checkout", "-b", branchName); err != nil {
+		return fmt.Errorf("failed to create branch %s: %v", branchName, err)
+	}
+
+	return nil
+}
+
+// DefaultEscalatedLabel is the label added to escalated tickets when escalated_label is not set
+const DefaultEscalatedLabel = "escalated"
+
+// escalatedLabelName returns the configured escalated label name
+func escalatedLabelName(config *LinearConfig) string {
+	if config.EscalatedLabel != "" {
+		return config.EscalatedLabel
+	}
+	return DefaultEscalatedLabel
+}
+
+// hasLabel reports whether the ticket carries a label with the given name (case-insensitive)
+func hasLabel(issue *Issue, name string) bool {
+	for _, label := range issue.Labels.Nodes {
+		if strings.EqualFold(label.Name, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// excludeEscalatedTickets drops tickets carrying the escalated label; a human removes the label to make them eligible again
+func excludeEscalatedTickets(tickets []Issue, config *LinearConfig) []Issue {
+	label := escalatedLabelName(config)
+	var result []Issue
+	for i := range tickets {
+		if hasLabel(&tickets[i], label) {
+			continue
+		}
+		result = append(result, tickets[i])
+	}
+	return result
+}
+
+// Ticket selection orders (ticket_order)
+const (
+	TicketOrderPriority = "priority"
+	TicketOrderBoard    = "board"
+)
+
+// orderTickets re-sorts tickets by Linear board position when ticket_order = "board".
+// Tickets arrive sorted by priority, which stays the tie-breaker.
+func orderTickets(tickets []Issue, config *LinearConfig) []Issue {
+	if strings.ToLower(config.TicketOrder) == TicketOrderBoard {
+		sort.SliceStable(tickets, func(i, j int) bool {
+			return tickets[i].SortOrder < tickets[j].SortOrder
+		})
+	}
+	return tickets
+}
+
+// splitWaitingSubissues separates sub-issues whose parent is not Done (when wait_for_parent is set) from the rest
+func splitWaitingSubissues(tickets []Issue, config *LinearConfig) ([]Issue, []Issue) {
+	if !config.WaitForParent {
+		return tickets, nil
+	}
+	var eligible, waiting []Issue
+	for _, ticket := range tickets {
+		if ticket.Parent != nil && ticket.Parent.State.Type != "completed" && ticket.Parent.State.Name != "Done" {
+			waiting = append(waiting, ticket)
+		} else {
+			eligible = append(eligible, ticket)
+		}
+	}
+	return eligible, waiting
+}
+
+// splitTeamlessTickets separates tickets without a team (orphaned/personal issues) from the rest.
+// Workflow state changes need a team, so team-less tickets cannot be automated.
+func splitTeamlessTickets(tickets []Issue) ([]Issue, []Issue) {
+	var eligible, teamless []Issue
+	for _, ticket := range tickets {
+		if ticket.Team.ID == "" {
+			teamless = append(teamless, ticket)
+		} else {
+			eligible = append(eligible, ticket)
+		}
+	}
+	return eligible, teamless
+}
+
+// Idle behaviors when there is no Todo ticket to work on (on_empty)
+const (
+	OnEmptyWait      = "wait"
+	OnEmptyExit      = "exit"
+	OnEmptyExitAfter = "exit-after"
+)
+
+// emptyPoller counts the manager's polls in a row that found nothing to work on (on_empty)
+type emptyPoller struct {
+	exitAfter int    // Empty polls in a row that end the session; 0 = keep waiting
+	onEmpty   string // on_empty as configured, for the exit message
+	polls     int
+}
+
+// idle ends the session when on_empty says so, and otherwise sleeps until the next poll
+func (p *emptyPoller) idle(reason string) bool {
+	p.polls++
+	if p.exitAfter > 0 && p.polls >= p.exitAfter {
+		statusf("ℹ️  %s. Nothing to do, exiting (on_empty = %q).\n", reason, p.onEmpty)
+		return true
+	}
+	statusf("ℹ️  %s. Sleeping for 1 minute and checking again...\n", reason)
+	clock.Sleep(ManagerPollInterval)
+	return false
+}
+
+// parseOnEmpty returns after how many empty polls in a row the manager exits for an on_empty value
+// ("wait" or empty = 0, never; "exit" = 1; "exit-after N" = N)
+func parseOnEmpty(value string) (int, error) {
+	fields := strings.Fields(strings.ToLower(value))
+	switch {
+	case len(fields) == 0 || (len(fields) == 1 && fields[0] == OnEmptyWait):
+		return 0, nil
+	case len(fields) == 1 && fields[0] == OnEmptyExit:
+		return 1, nil
+	case len(fields) == 2 && fields[0] == OnEmptyExitAfter:
+		var polls int
+		if _, err := fmt.Sscanf(fields[1], "%d", &polls); err == nil && polls >= 1 {
+			return polls, nil
+		}
+	}
+	return 0, fmt.Errorf("on_empty must be %q, %q, or %q (N >= 1), got %q", OnEmptyWait, OnEmptyExit, OnEmptyExitAfter+" N", value)
+}
+
+// Ticket batching (batch_by)
+const (
+	BatchByParent       = "parent"
+	BatchByLabel        = "label"
+	BatchLabelPrefix    = "batch:"
+	DefaultMaxBatchSize = 5
+)
+
+// ticketBatchKey returns the group a ticket is batched by: its parent issue or its "batch:<name>" label.
+// Returns "" when batching is off or the ticket has no group.
+func ticketBatchKey(issue *Issue, config *LinearConfig) string {
+	switch strings.ToLower(config.BatchBy) {
+	case BatchByParent:
+		if issue.Parent != nil {
+			return issue.Parent.ID
+		}
+	case BatchByLabel:
+		for _, label := range issue.Labels.Nodes {
+			name := strings.TrimSpace(label.Name)
+			if strings.HasPrefix(strings.ToLower(name), BatchLabelPrefix) {
+				return strings.ToLower(name)
+			}
+		}
+	}
+	return ""
+}
+
+// batchCandidates returns the other tickets in the lead ticket's group that share its base branch,
+// in selection order, capped so the batch stays within max_batch_size
+func batchCandidates(lead *Issue, tickets []Issue, config *LinearConfig) []*Issue {
+	key := ticketBatchKey(lead, config)
+	if key == "" {
+		return nil
+	}
+	limit := config.MaxBatchSize
+	if limit == 0 {
+		limit = DefaultMaxBatchSize
+	}
+
+	leadBase := ticketBaseBranch(lead, config.BaseBranch)
+	var candidates []*Issue
+	for i := range tickets {
+		if len(candidates) >= limit-1 {
+			break
 		}
+		ticket := &tickets[i]
+		if ticket.ID == lead.ID || ticketBatchKey(ticket, config) != key || ticketBaseBranch(ticket, config.BaseBranch) != leadBase {
+			continue
+		}
+		candidates = append(candidates, ticket)
+	}
+	return candidates
+}
+
+// batchPRDDescription builds the PRD description for a batch: every ticket's title and description, in order
+func batchPRDDescription(lead *Issue, batch []*Issue) string {
+	parts := []string{"Related tickets, to be worked one after another on the same branch. Keep the PRD tasks grouped by ticket, in this order."}
+	for _, ticket := range append([]*Issue{lead}, batch...) {
+		parts = append(parts, fmt.Sprintf("## %s: %s\n\n%s", ticket.Identifier, ticket.Title, ticket.Description))
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// Plan approval defaults (require_plan_approval)
+const (
+	DefaultPlanApprovalLabel   = "approved"
+	DefaultPlanApprovalTimeout = 60 // minutes
+	PlanApprovalPollInterval   = 30 * time.Second
+	maxPlanCommentChars        = 10000
+)
+
+// awaitPlanApproval posts the current PLAN.md to the ticket and polls until the ticket carries the approval label.
+// Returns false (and comments on the ticket) if the label does not appear within plan_approval_timeout.
+// The label stays on the ticket, so once a ticket is approved its later plans are posted but not waited on.
+func awaitPlanApproval(client IssueProvider, config *LinearConfig, issue *Issue, iteration int) (bool, error) {
+	label := config.PlanApprovalLabel
+	if label == "" {
+		label = DefaultPlanApprovalLabel
 	}
+	timeout := config.PlanApprovalTimeout
+	if timeout == 0 {
+		timeout = DefaultPlanApprovalTimeout
+	}
+
+	plan, err := readFileContent(PlanFile)
+	if err != nil {
+		statusf("⚠️  Warning: no plan to post for approval (%v), continuing\n", err)
+		return true, nil
+	}
+	if len(plan) > maxPlanCommentChars {
+		plan = plan[:maxPlanCommentChars] + "\n\n... (plan truncated)"
+	}
+
+	comment := fmt.Sprintf("📝 **Plan for iteration %d**\n\nRalph will implement this plan once the ticket has the `%s` label (waiting up to %d minutes).\n\n---\n\n%s", iteration, label, timeout, plan)
+	if err := client.addTicketComment(issue.ID, comment, []string{config.EscalateUser}); err != nil {
+		return false, fmt.Errorf("failed to post plan for approval: %v", err)
+	}
+
+	statusf("⏸️  Waiting up to %d minutes for the %q label on %s...\n", timeout, label, ticketDisplayName(issue))
+	deadline := clock.Now().Add(time.Duration(timeout) * time.Minute)
+	for {
+		current, err := client.getIssue(issue.ID)
+		if err != nil {
+			statusf("⚠️  Warning: failed to check plan approval: %v\n", err)
+		} else if current != nil && hasLabel(current, label) {
+			statusf("✅ Plan approved\n")
+			return true, nil
+		}
+
+		if !clock.Now().Before(deadline) {
+			break
+		}
+		clock.Sleep(PlanApprovalPollInterval)
+	}
+
+	statusf("⏰ Plan not approved within %d minutes\n", timeout)
+	timeoutComment := fmt.Sprintf("⏰ The plan for iteration %d was not approved (no `%s` label) within %d minutes, so Ralph did not implement it.", iteration, label, timeout)
+	if err := client.addTicketComment(issue.ID, timeoutComment, nil); err != nil {
+		statusf("⚠️  Warning: failed to add comment: %v\n", err)
+	}
+	return false, nil
+}
+
+// escalateTicket labels the ticket as escalated and moves it back to Todo, so it is not picked again until a human removes the label
+func escalateTicket(client IssueProvider, config *LinearConfig, issue *Issue) {
+	label := escalatedLabelName(config)
+	if err := client.addLabel(issue, label); err != nil {
+		statusf("⚠️  Warning: %v\n", err)
+	}
+
+	if err := client.updateTicketStatus(issue.ID, issue.Team.ID, "Todo"); err != nil {
+		statusf("⚠️  Warning: failed to update ticket status: %v\n", err)
+	}
+}
 
+// escalateBatch escalates the tickets batched with lead along with it, pointing them at lead for the details
+func escalateBatch(client IssueProvider, config *LinearConfig, lead *Issue, batch []*Issue) {
+	for _, ticket := range batch {
+		comment := fmt.Sprintf("⚠️  Escalated together with %s, which this ticket was batched with. See that ticket for details.", ticketDisplayName(lead))
+		if err := client.addTicketComment(ticket.ID, comment, nil); err != nil {
+			statusf("⚠️  Warning: failed to add comment: %v\n", err)
+		}
+		escalateTicket(client, config, ticket)
+	}
+}
+
+// BaseBranchLabelPrefix marks a Linear label that overrides the base branch for a ticket (e.g. "base:release-2.0")
+const BaseBranchLabelPrefix = "base:"
+
+// ticketBaseBranch returns the base branch for a ticket: a "base:<branch>" label wins over the configured base branch
+func ticketBaseBranch(issue *Issue, configured string) string {
+	for _, label := range issue.Labels.Nodes {
+		name := strings.TrimSpace(label.Name)
+		if strings.HasPrefix(strings.ToLower(name), BaseBranchLabelPrefix) {
+			if branch := strings.TrimSpace(name[len(BaseBranchLabelPrefix):]); branch != "" {
+				return branch
+			}
+		}
+	}
+	return configured
+}
+
+// gitBranchExists reports whether a branch exists locally or on origin
+func gitBranchExists(branch string) bool {
+	if gitRun("show-ref", "--verify", "--quiet", "refs/heads/"+branch) == nil {
+		return true
+	}
+	return gitRun("show-ref", "--verify", "--quiet", "refs/remotes/origin/"+branch) == nil
+}
+
+// checkCleanWorkingTree returns an error listing modified tracked files if the working tree is dirty
+func checkCleanWorkingTree() error {
+	output, err := gitOutput("status", "--porcelain", "--untracked-files=no")
+	if err != nil {
+		return fmt.Errorf("failed to check working tree status: %v", err)
+	}
+
+	status := strings.TrimSpace(string(output))
+	if status != "" {
+		return fmt.Errorf("working tree has uncommitted changes; commit or stash them (git stash) before running manager mode:\n%s", status)
+	}
 	return nil
 }
 
-// validateGitSetup validates that git remote is configured and GitHub CLI is available
-func validateGitSetup() error {
+// runGitWithRetry runs a git command, retrying a few times with a short delay.
+// Checkouts can fail transiently when something else (e.g. an editor's file watcher) holds the index lock.
+func runGitWithRetry(args ...string) error {
+	var lastErr error
+	for attempt := 1; attempt <= GitRetryAttempts; attempt++ {
+		output, err := gitCombinedOutput(args...)
+		if err == nil {
+			return nil
+		}
+		lastErr = fmt.Errorf("%v: %s", err, strings.TrimSpace(string(output)))
+
+		if attempt < GitRetryAttempts {
+			statusf("⚠️  Warning: git %s failed (attempt %d/%d), retrying: %s\n", strings.Join(args, " "), attempt, GitRetryAttempts, strings.TrimSpace(string(output)))
+			clock.Sleep(GitRetryDelay)
+		}
+	}
+	return lastErr
+}
+
+// ticketDisplayName returns the ticket identifier and title for status output
+func ticketDisplayName(issue *Issue) string {
+	if issue.Identifier == "" {
+		return issue.Title
+	}
+	return fmt.Sprintf("%s (%s)", issue.Identifier, issue.Title)
+}
+
+// stashFailedTicketWork stashes uncommitted changes a failed ticket left behind (naming its branch),
+// so the next ticket starts from a clean working tree without losing the work
+func stashFailedTicketWork(branchName string) {
+	output, err := gitOutput("status", "--porcelain")
+	if err != nil || strings.TrimSpace(string(output)) == "" {
+		return
+	}
+	message := fmt.Sprintf("ralph: uncommitted work from failed ticket branch %s", branchName)
+	if output, err := gitCombinedOutput("stash", "push", "--include-untracked", "-m", message); err != nil {
+		statusf("⚠️  Warning: failed to stash leftover changes: %v: %s\n", err, strings.TrimSpace(string(output)))
+		return
+	}
+	statusf("ℹ️  Stashed leftover changes (%q); recover them with git stash list / git stash pop\n", message)
+}
+
+// printManagerSummary prints the tickets that succeeded and failed this session
+func printManagerSummary(succeeded, failed []string) {
+	fmt.Println()
+	statusf("📊 Manager session summary: %d succeeded, %d failed\n", len(succeeded), len(failed))
+	for _, ticket := range succeeded {
+		fmt.Printf("   ✅ %s\n", ticket)
+	}
+	for _, ticket := range failed {
+		fmt.Printf("   ❌ %s\n", ticket)
+	}
+}
+
+// validateGitSetup validates that git remote is configured and that pull requests can be created on its host.
+// Returns the PRCreator matching the remote (GitHub via gh, or Bitbucket via its REST API).
+func validateGitSetup(config *LinearConfig) (PRCreator, error) {
 	// Check if git remote is configured
-	cmd := exec.Command("git", "remote", "-v")
-	output, err := cmd.Output()
+	output, err := gitOutput("remote", "-v")
 	if err != nil {
-		return fmt.Errorf("failed to check git remotes: %v", err)
+		return nil, fmt.Errorf("failed to check git remotes: %v", err)
 	}
 
 	remoteOutput := strings.TrimSpace(string(output))
 	if remoteOutput == "" {
-		return fmt.Errorf("no git remote configured. Please add a remote with: git remote add origin <url>")
+		return nil, fmt.Errorf("no git remote configured. Please add a remote with: git remote add origin <url>")
 	}
 
-	// Check if remote URL is GitHub (github.com)
+	// Select PR creator based on the remote host (github.com or bitbucket.org)
+	var creator PRCreator
 	lines := strings.Split(remoteOutput, "\n")
-	hasGitHubRemote := false
 	for _, line := range lines {
 		if strings.Contains(line, "github.com") {
-			hasGitHubRemote = true
+			creator = &GitHubPRCreator{}
+			break
+		}
+		if strings.Contains(line, "bitbucket.org") {
+			remoteURL, err := getOriginRemoteURL()
+			if err != nil {
+				return nil, err
+			}
+			bitbucket, err := NewBitbucketPRCreator(remoteURL, config)
+			if err != nil {
+				return nil, err
+			}
+			creator = bitbucket
 			break
 		}
 	}
 
-	if !hasGitHubRemote {
-		return fmt.Errorf("git remote does not appear to be GitHub. PR creation requires GitHub")
-	}
-
-	// Check if GitHub CLI is installed
-	ghCmd := exec.Command("gh", "--version")
-	if err := ghCmd.Run(); err != nil {
-		return fmt.Errorf("GitHub CLI (gh) is not installed. Please install it from https://cli.github.com/")
+	if creator == nil {
+		return nil, fmt.Errorf("git remote does not appear to be GitHub or Bitbucket. PR creation requires GitHub or Bitbucket Cloud")
 	}
 
-	// Check if GitHub CLI is authenticated
-	authCmd := exec.Command("gh", "auth", "status")
-	authOutput, err := authCmd.CombinedOutput()
-	if err != nil {
-		return fmt.Errorf("GitHub CLI is not authenticated. Please run: gh auth login\nOutput: %s", string(authOutput))
+	if err := creator.Validate(); err != nil {
+		return nil, err
 	}
 
-	// Verify authentication is valid (check for "Logged in" in output)
-	if !strings.Contains(string(authOutput), "Logged in") {
-		return fmt.Errorf("GitHub CLI authentication appears invalid. Please run: gh auth login")
+	// A configured base branch must exist up front, rather than failing mid-ticket on checkout
+	if config.BaseBranch != "" && !gitBranchExists(config.BaseBranch) {
+		return nil, fmt.Errorf("base_branch %q does not exist locally or on origin. Fetch it with: git fetch origin %s", config.BaseBranch, config.BaseBranch)
 	}
 
 	// Check if .ralph directory is in .gitignore
@@ -571,9 +1501,9 @@ func validateGitSetup() error {
 		// .gitignore doesn't exist, create it with .ralph/
 		gitignoreContent = []byte(".ralph/\n")
 		if err := os.WriteFile(gitignorePath, gitignoreContent, 0644); err != nil {
-			return fmt.Errorf("failed to create .gitignore: %v", err)
+			return nil, fmt.Errorf("failed to create .gitignore: %v", err)
 		}
-		fmt.Printf("ℹ️  Created .gitignore with .ralph/ entry\n")
+		statusf("ℹ️  Created .gitignore with .ralph/ entry\n")
 	} else {
 		// Check if .ralph is already in .gitignore
 		content := string(gitignoreContent)
@@ -591,7 +1521,7 @@ func validateGitSetup() error {
 			// Append .ralph/ to .gitignore
 			file, err := os.OpenFile(gitignorePath, os.O_APPEND|os.O_WRONLY, 0644)
 			if err != nil {
-				return fmt.Errorf("failed to open .gitignore for writing: %v", err)
+				return nil, fmt.Errorf("failed to open .gitignore for writing: %v", err)
 			}
 			defer file.Close()
 			
@@ -599,39 +1529,37 @@ func validateGitSetup() error {
 			contentBytes := gitignoreContent
 			if len(contentBytes) > 0 && contentBytes[len(contentBytes)-1] != '\n' {
 				if _, err := file.WriteString("\n"); err != nil {
-					return fmt.Errorf("failed to write to .gitignore: %v", err)
+					return nil, fmt.Errorf("failed to write to .gitignore: %v", err)
 				}
 			}
 			
 			// Add .ralph/ entry
 			if _, err := file.WriteString(".ralph/\n"); err != nil {
-				return fmt.Errorf("failed to write .ralph/ to .gitignore: %v", err)
+				return nil, fmt.Errorf("failed to write .ralph/ to .gitignore: %v", err)
 			}
-			fmt.Printf("ℹ️  Added .ralph/ to .gitignore\n")
+			statusf("ℹ️  Added .ralph/ to .gitignore\n")
 		}
 	}
 
-	return nil
+	return creator, nil
 }
 
 // pushBranchToRemote pushes a branch to the remote repository
 func pushBranchToRemote(branchName string) error {
 	// Check if branch is already pushed
-	cmd := exec.Command("git", "ls-remote", "--heads", "origin", branchName)
-	output, err := cmd.Output()
+	output, err := gitOutput("ls-remote", "--heads", "origin", branchName)
 	if err == nil && strings.TrimSpace(string(output)) != "" {
 		// Branch already exists on remote, try to push anyway (might need to update)
-		fmt.Printf("ℹ️  Branch %s already exists on remote, pushing updates...\n", branchName)
+		statusf("ℹ️  Branch %s already exists on remote, pushing updates...\n", branchName)
 	}
 
 	// Push branch to remote with upstream tracking
-	cmd = exec.Command("git", "push", "-u", "origin", branchName)
-	output, err = cmd.CombinedOutput()
+	output, err = gitCombinedOutput("push", "-u", "origin", branchName)
 	if err != nil {
 		// Check if error is because branch is already up to date
 		outputStr := string(output)
 		if strings.Contains(outputStr, "Everything up-to-date") {
-			fmt.Printf("ℹ️  Branch %s is already up to date on remote\n", branchName)
+			statusf("ℹ️  Branch %s is already up to date on remote\n", branchName)
 			return nil
 		}
 		return fmt.Errorf("failed to push branch to remote: %v\nOutput: %s", err, outputStr)
@@ -640,83 +1568,172 @@ func pushBranchToRemote(branchName string) error {
 	return nil
 }
 
-// createPullRequest creates a pull request using GitHub CLI
-func createPullRequest(branchName, baseBranch, issueIdentifier, issueTitle, issueURL, issueDescription string) (string, error) {
+// restoreMissingBranch handles a resumed ticket whose local branch was deleted: a branch that was pushed is
+// fetched back from origin, otherwise createGitBranch recreates it and this warns that the earlier work on it
+// is gone. Returns the base branch to pass to createGitBranch: for a recreated branch, the ticket's
+// base:<branch> label (as on a fresh start) or else configuredBase.
+func restoreMissingBranch(client IssueProvider, issueID, branchName, configuredBase string) string {
+	if gitRun("show-ref", "--verify", "--quiet", "refs/heads/"+branchName) == nil {
+		return configuredBase
+	}
+
+	output, err := gitOutput("ls-remote", "--heads", "origin", branchName)
+	if err == nil && strings.TrimSpace(string(output)) != "" {
+		output, err := gitCombinedOutput("fetch", "origin", branchName+":"+branchName)
+		if err == nil {
+			statusf("ℹ️  Branch %s no longer exists locally; restored it from origin\n", branchName)
+			return configuredBase
+		}
+		statusf("⚠️  Warning: failed to fetch branch %s from origin: %v\nOutput: %s\n", branchName, err, string(output))
+	}
+
+	baseBranch := configuredBase
+	if issue, err := client.getIssue(issueID); err != nil {
+		statusf("⚠️  Warning: failed to fetch ticket %s for its base branch label: %v\n", issueID, err)
+	} else {
+		baseBranch = ticketBaseBranch(issue, configuredBase)
+	}
+	from := baseBranch
+	if from == "" {
+		from = "the default branch"
+	}
+	statusf("⚠️  Warning: branch %s no longer exists locally or on origin; recreating it from %s. "+
+		"Work from earlier iterations that was never pushed is lost, so the ticket continues from the base\n", branchName, from)
+	return baseBranch
+}
+
+// findMergeConflicts fetches the base branch from origin and returns the files that would conflict
+// when merging branchName into it. Requires git 2.38+ (merge-tree --write-tree).
+func findMergeConflicts(baseBranch, branchName string) ([]string, error) {
+	if output, err := gitCombinedOutput("fetch", "origin", baseBranch); err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %v\nOutput: %s", baseBranch, err, string(output))
+	}
+
+	output, err := gitOutput("merge-tree", "--write-tree", "--name-only", "--no-messages", "-z", "origin/"+baseBranch, branchName)
+	if err == nil {
+		return nil, nil
+	}
+	exitErr, ok := err.(*exec.ExitError)
+	if !ok || exitErr.ExitCode() != 1 {
+		return nil, fmt.Errorf("git merge-tree failed (git 2.38+ required): %v", err)
+	}
+
+	// Exit code 1: the first entry is the tree OID, followed by the conflicted file names
+	entries := splitNUL(output)
+	if len(entries) < 2 {
+		return nil, nil
+	}
+	return entries[1:], nil
+}
+
+// resolveBaseConflicts checks branchName against the base branch and, when autoMerge is set, tries merging
+// the base into the branch. Returns the files still in conflict (empty when the branch merges cleanly).
+func resolveBaseConflicts(baseBranch, branchName string, autoMerge bool) ([]string, error) {
+	conflicts, err := findMergeConflicts(baseBranch, branchName)
+	if err != nil || len(conflicts) == 0 || !autoMerge {
+		return conflicts, err
+	}
+
+	statusf("🔀 Branch conflicts with %s, attempting to merge it in...\n", baseBranch)
+	if output, err := gitCombinedOutput("merge", "--no-edit", "origin/"+baseBranch); err != nil {
+		statusf("⚠️  Automatic merge failed: %s\n", strings.TrimSpace(string(output)))
+		if err := gitRun("merge", "--abort"); err != nil {
+			return nil, fmt.Errorf("failed to abort merge: %v", err)
+		}
+		return conflicts, nil
+	}
+
+	statusf("✅ Merged %s into %s\n", baseBranch, branchName)
+	return nil, nil
+}
+
+// createPullRequest pushes the branch and opens a pull request using the given PRCreator
+// Tickets in batch (batch_by) are closed by the same pull request.
+func createPullRequest(creator PRCreator, branchName, baseBranch, issueIdentifier, issueTitle, issueURL, issueDescription string, batch []*Issue) (string, error) {
 	// Push branch first
 	if err := pushBranchToRemote(branchName); err != nil {
 		return "", fmt.Errorf("failed to push branch: %v", err)
 	}
 
-	// Build PR title
-	prTitle := issueTitle
-	if issueIdentifier != "" {
-		prTitle = fmt.Sprintf("%s: %s", issueIdentifier, issueTitle)
+	// Reuse an open pull request from an earlier attempt (e.g. the push succeeded but PR creation failed)
+	if prURL, err := creator.FindPullRequest(branchName); err != nil {
+		statusf("⚠️  Warning: %v, creating a new pull request\n", err)
+	} else if prURL != "" {
+		statusf("ℹ️  Reusing existing pull request: %s\n", prURL)
+		return prURL, nil
 	}
 
-	// Build PR body
-	var bodyParts []string
-	bodyParts = append(bodyParts, fmt.Sprintf("Closes Linear ticket: %s", issueURL))
-	if issueDescription != "" {
-		// Truncate description if too long (GitHub PR body limit is ~65KB, but keep it reasonable)
-		desc := issueDescription
-		if len(desc) > 5000 {
-			desc = desc[:5000] + "\n\n... (description truncated)"
+	// Build PR title
+	identifiers := issueIdentifier
+	for _, ticket := range batch {
+		if ticket.Identifier != "" {
+			identifiers += ", " + ticket.Identifier
 		}
-		bodyParts = append(bodyParts, "\n## Description")
-		bodyParts = append(bodyParts, desc)
 	}
-	bodyParts = append(bodyParts, fmt.Sprintf("\n## Branch\n`%s`", branchName))
-	bodyParts = append(bodyParts, "\n---\n*This PR was automatically created by Ralph*")
-
-	prBody := strings.Join(bodyParts, "\n")
+	prTitle := issueTitle
+	if identifiers != "" {
+		prTitle = fmt.Sprintf("%s: %s", strings.TrimPrefix(identifiers, ", "), issueTitle)
+	}
 
-	// Create PR using GitHub CLI
-	cmd := exec.Command("gh", "pr", "create",
-		"--title", prTitle,
-		"--body", prBody,
-		"--base", baseBranch,
-		"--head", branchName,
-	)
+	// Truncate description if too long (GitHub PR body limit is ~65KB, but keep it reasonable)
+	desc := issueDescription
+	if len(desc) > 5000 {
+		desc = desc[:5000] + "\n\n... (description truncated)"
+	}
 
-	output, err := cmd.CombinedOutput()
+	// Build PR body, from .ralph/pr_template.md when present
+	prBody, err := renderPRTemplate(strings.TrimPrefix(identifiers, ", "), issueTitle, issueURL, desc, branchName)
 	if err != nil {
-		outputStr := string(output)
-		// Check if PR already exists
-		if strings.Contains(outputStr, "already exists") || strings.Contains(outputStr, "pull request already exists") {
-			// Try to get the existing PR URL
-			getPRCmd := exec.Command("gh", "pr", "view", branchName, "--json", "url", "--jq", ".url")
-			prOutput, prErr := getPRCmd.Output()
-			if prErr == nil {
-				prURL := strings.TrimSpace(string(prOutput))
-				if prURL != "" {
-					fmt.Printf("ℹ️  Pull request already exists: %s\n", prURL)
-					return prURL, nil
-				}
+		statusf("⚠️  Warning: %v, using default PR body\n", err)
+	}
+	if prBody == "" {
+		var bodyParts []string
+		// "Closes <identifier>" is a magic word Linear's GitHub integration recognizes: it links the PR
+		// and moves the ticket along as the PR is merged
+		if issueIdentifier != "" {
+			bodyParts = append(bodyParts, fmt.Sprintf("Closes %s\n\nTicket: %s", issueIdentifier, issueURL))
+		} else {
+			bodyParts = append(bodyParts, fmt.Sprintf("Closes ticket: %s", issueURL))
+		}
+		for _, ticket := range batch {
+			if ticket.Identifier != "" {
+				bodyParts = append(bodyParts, fmt.Sprintf("Closes %s (%s)", ticket.Identifier, ticket.URL))
+			} else {
+				bodyParts = append(bodyParts, fmt.Sprintf("Closes ticket: %s", ticket.URL))
 			}
-			return "", fmt.Errorf("pull request already exists for branch %s", branchName)
 		}
-		return "", fmt.Errorf("failed to create pull request: %v\nOutput: %s", err, outputStr)
-	}
+		if desc != "" {
+			bodyParts = append(bodyParts, "\n## Description")
+			bodyParts = append(bodyParts, desc)
+		}
+		bodyParts = append(bodyParts, fmt.Sprintf("\n## Branch\n`%s`", branchName))
+		bodyParts = append(bodyParts, "\n---\n*This PR was automatically created by Ralph*")
 
-	// Extract PR URL from output
-	outputStr := strings.TrimSpace(string(output))
-	// GitHub CLI typically outputs the PR URL
-	if strings.HasPrefix(outputStr, "http") {
-		return outputStr, nil
+		prBody = strings.Join(bodyParts, "\n")
 	}
 
-	// If URL not in output, try to get it
-	getPRCmd := exec.Command("gh", "pr", "view", branchName, "--json", "url", "--jq", ".url")
-	prOutput, err := getPRCmd.Output()
-	if err == nil {
-		prURL := strings.TrimSpace(string(prOutput))
-		if prURL != "" {
-			return prURL, nil
+	return creator.CreatePullRequest(prTitle, prBody, baseBranch, branchName)
+}
+
+// renderPRTemplate renders .ralph/pr_template.md, replacing {{identifier}}, {{title}}, {{url}}, {{description}} and {{branch}}.
+// Returns an empty body when no template exists.
+func renderPRTemplate(identifier, title, issueURL, description, branch string) (string, error) {
+	data, err := os.ReadFile(PRTemplateFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
 		}
+		return "", fmt.Errorf("failed to read %s: %v", PRTemplateFile, err)
 	}
 
-	// Fallback: return a message indicating PR was created
-	return "https://github.com/<repo>/pull/<number> (created but URL not retrieved)", nil
+	replacer := strings.NewReplacer(
+		"{{identifier}}", identifier,
+		"{{title}}", title,
+		"{{url}}", issueURL,
+		"{{description}}", description,
+		"{{branch}}", branch,
+	)
+	return strings.TrimSpace(replacer.Replace(string(data))), nil
 }
 
 // saveManagerState saves the manager state to file
@@ -735,6 +1752,21 @@ func saveManagerState(state *ManagerState) error {
 	fmt.Fprintf(file, "issue_id=%s\n", state.IssueID)
 	fmt.Fprintf(file, "branch_name=%s\n", state.BranchName)
 	fmt.Fprintf(file, "iteration=%d\n", state.Iteration)
+	if state.ProgressCommentID != "" {
+		fmt.Fprintf(file, "progress_comment_id=%s\n", state.ProgressCommentID)
+	}
+	if len(state.BatchIssueIDs) > 0 {
+		fmt.Fprintf(file, "batch_issue_ids=%s\n", strings.Join(state.BatchIssueIDs, ","))
+	}
+	if state.Phase != "" {
+		fmt.Fprintf(file, "phase=%s\n", state.Phase)
+	}
+	if state.PRURL != "" {
+		fmt.Fprintf(file, "pr_url=%s\n", state.PRURL)
+	}
+	if state.ArtifactsPosted {
+		fmt.Fprintf(file, "artifacts_posted=true\n")
+	}
 
 	return nil
 }
@@ -774,6 +1806,16 @@ func loadManagerState() (*ManagerState, error) {
 			state.BranchName = value
 		case "iteration":
 			fmt.Sscanf(value, "%d", &state.Iteration)
+		case "progress_comment_id":
+			state.ProgressCommentID = value
+		case "batch_issue_ids":
+			state.BatchIssueIDs = strings.Split(value, ",")
+		case "phase":
+			state.Phase = value
+		case "pr_url":
+			state.PRURL = value
+		case "artifacts_posted":
+			state.ArtifactsPosted = value == "true"
 		}
 	}
 
@@ -794,18 +1836,17 @@ func clearManagerState() error {
 
 // getCurrentGitBranch gets the current git branch name
 func getCurrentGitBranch() (string, error) {
-	cmd := exec.Command("git", "rev-parse", "--abbrev-ref", "HEAD")
-	output, err := cmd.Output()
+	output, err := gitOutput("rev-parse", "--abbrev-ref", "HEAD")
 	if err != nil {
 		return "", fmt.Errorf("failed to get current branch: %v", err)
 	}
 	return strings.TrimSpace(string(output)), nil
 }
 
-// extractIssueIDFromBranch extracts Linear issue ID from branch name
+// issueIDFromBranch extracts the Linear issue ID from a branch name in the legacy UUID scheme
 // Branch format: linear/{issue-id}-{slug}
 // Returns issue ID if pattern matches, empty string otherwise
-func extractIssueIDFromBranch(branchName string) string {
+func (c *LinearClient) issueIDFromBranch(branchName string) string {
 	// Pattern: linear/{issue-id}-{slug}
 	// Linear issue IDs are UUIDs (format: xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx)
 	pattern := regexp.MustCompile(`^linear/([a-f0-9-]+)-`)
@@ -817,9 +1858,9 @@ func extractIssueIDFromBranch(branchName string) string {
 }
 
 // detectBranchBasedRecovery detects recovery from current branch
-// Checks if we're on a branch that matches a Linear ticket pattern
+// Checks if we're on a branch that matches the ticket branch scheme (branch_template)
 // and if that ticket is "In Progress"
-func detectBranchBasedRecovery(client *LinearClient) (*ManagerState, error) {
+func detectBranchBasedRecovery(client IssueProvider, config *LinearConfig) (*ManagerState, error) {
 	// Get current branch
 	branchName, err := getCurrentGitBranch()
 	if err != nil {
@@ -827,10 +1868,10 @@ func detectBranchBasedRecovery(client *LinearClient) (*ManagerState, error) {
 		return nil, nil
 	}
 
-	// Check if branch matches Linear pattern
-	issueID := extractIssueIDFromBranch(branchName)
+	// Check if branch matches the tracker's ticket branch pattern
+	issueID := issueIDFromBranchName(client, config, branchName)
 	if issueID == "" {
-		// Branch doesn't match pattern - not a Linear branch
+		// Branch doesn't match pattern - not a ticket branch
 		return nil, nil
 	}
 
@@ -838,7 +1879,7 @@ func detectBranchBasedRecovery(client *LinearClient) (*ManagerState, error) {
 	valid, err := client.verifyIssueState(issueID, "In Progress")
 	if err != nil {
 		// Error checking ticket - log warning but don't fail
-		fmt.Printf("⚠️  Warning: Could not verify ticket state for branch %s: %v\n", branchName, err)
+		statusf("⚠️  Warning: Could not verify ticket state for branch %s: %v\n", branchName, err)
 		return nil, nil
 	}
 
@@ -886,15 +1927,187 @@ func (c *LinearClient) verifyIssueState(issueID, expectedState string) (bool, er
 		} `json:"issue"`
 	}
 
-	if err := json.Unmarshal(data, &result); err != nil {
-		return false, fmt.Errorf("failed to parse issue: %v", err)
+	if err := json.Unmarshal(data, &result); err != nil {
+		return false, fmt.Errorf("failed to parse issue: %v", err)
+	}
+
+	if result.Issue.ID == "" {
+		return false, nil // Issue doesn't exist
+	}
+
+	return result.Issue.State.Name == expectedState, nil
+}
+
+// getIssue fetches a single ticket, or returns nil if it doesn't exist
+func (c *LinearClient) getIssue(issueID string) (*Issue, error) {
+	query := `
+		query($issueId: String!) {
+			issue(id: $issueId) {
+				id
+				identifier
+				title
+				description
+				priority
+				estimate
+				state {
+					name
+					id
+				}
+				team {
+					id
+				}
+				labels {
+					nodes {
+						id
+						name
+					}
+				}
+				url
+			}
+		}
+	`
+
+	variables := map[string]interface{}{
+		"issueId": issueID,
+	}
+
+	data, err := c.executeGraphQL(query, variables)
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Issue Issue `json:"issue"`
+	}
+
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse issue: %v", err)
+	}
+
+	if result.Issue.ID == "" {
+		return nil, nil
+	}
+	return &result.Issue, nil
+}
+
+// ticketIterationBudget returns the number of loop iterations to spend on a ticket and which setting determined it.
+// max_iterations_per_ticket overrides the command-line value; iterations_per_estimate_point scales with the
+// ticket estimate, capped by max_iterations_per_ticket when both are set.
+func ticketIterationBudget(config *LinearConfig, issue *Issue, defaultIterations int) (int, string) {
+	budget := defaultIterations
+	source := "command-line iterations"
+	if config.MaxIterationsPerTicket > 0 {
+		budget = config.MaxIterationsPerTicket
+		source = "max_iterations_per_ticket"
+	}
+
+	if config.IterationsPerEstimatePoint > 0 && issue.Estimate != nil && *issue.Estimate > 0 {
+		estimated := int(math.Ceil(*issue.Estimate * float64(config.IterationsPerEstimatePoint)))
+		if config.MaxIterationsPerTicket == 0 || estimated < budget {
+			budget = estimated
+			source = fmt.Sprintf("ticket estimate %.0f x %d", *issue.Estimate, config.IterationsPerEstimatePoint)
+		}
+	}
+
+	return budget, source
+}
+
+// ClaimMarker starts the comment a manager instance posts to claim a ticket, followed by its instance ID
+const ClaimMarker = "ralph-claim:"
+
+// ClaimReleasedMarker is appended to a claim comment whose instance backed off, so it no longer counts
+const ClaimReleasedMarker = "(backed off)"
+
+// ClaimWindow is how far back claim comments compete: only claims this close together can be a race.
+// A claim older than this belongs to an earlier pickup of the ticket (e.g. before it was escalated back to Todo).
+const ClaimWindow = 2 * time.Minute
+
+// managerInstanceID identifies this manager process in claim comments
+var managerInstanceID = newManagerInstanceID()
+
+// newManagerInstanceID returns "<host>-<pid>-<random>", unique across manager instances sharing a project
+func newManagerInstanceID() string {
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		host = "ralph"
+	}
+	var b [4]byte
+	rand.Read(b[:])
+	return fmt.Sprintf("%s-%d-%x", host, os.Getpid(), b)
+}
+
+// claimTicket claims a Todo ticket for this instance before any work starts. Moving it to "In Progress"
+// alone can't tell two racing managers apart (both see Todo, both write In Progress, both read it back),
+// so each posts a ralph-claim:<instance-id> comment and the earliest claim in the tracker's own order
+// wins; the others mark theirs as backed off. The winner then moves the ticket to "In Progress".
+// Returns false when the ticket left Todo or another instance's claim came first.
+func claimTicket(client IssueProvider, issue *Issue) (bool, error) {
+	stillTodo, err := client.verifyIssueState(issue.ID, "Todo")
+	if err != nil {
+		return false, err
+	}
+	if !stillTodo {
+		return false, nil
+	}
+
+	claim := ClaimMarker + managerInstanceID
+	commentID, err := client.createTicketComment(issue.ID, fmt.Sprintf("🤖 %s picking this ticket up", claim), nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to post claim comment: %v", err)
+	}
+	comments, err := client.listTicketComments(issue.ID)
+	if err != nil {
+		return false, fmt.Errorf("failed to read claim comments: %v", err)
+	}
+
+	if winner := winningClaim(comments, commentID); winner != commentID {
+		backedOff := fmt.Sprintf("🤖 %s %s: another manager instance claimed this ticket first", claim, ClaimReleasedMarker)
+		if err := client.updateTicketComment(issue.ID, commentID, backedOff); err != nil {
+			statusf("⚠️  Warning: failed to mark the claim comment as backed off: %v\n", err)
+		}
+		return false, nil
 	}
 
-	if result.Issue.ID == "" {
-		return false, nil // Issue doesn't exist
+	if err := client.updateTicketStatus(issue.ID, issue.Team.ID, "In Progress"); err != nil {
+		return false, err
 	}
 
-	return result.Issue.State.Name == expectedState, nil
+	// Re-fetch to confirm the transition stuck before doing any work
+	return client.verifyIssueState(issue.ID, "In Progress")
+}
+
+// winningClaim returns the ID of the earliest live claim comment within ClaimWindow of ours (commentID),
+// ties broken by comment ID. Returns "" when our own claim is not among the comments.
+func winningClaim(comments []TicketComment, commentID string) string {
+	var ours *TicketComment
+	for i := range comments {
+		if comments[i].ID == commentID {
+			ours = &comments[i]
+		}
+	}
+	if ours == nil {
+		return ""
+	}
+
+	winner := ours
+	for i := range comments {
+		comment := &comments[i]
+		if !strings.Contains(comment.Body, ClaimMarker) || strings.Contains(comment.Body, ClaimReleasedMarker) {
+			continue
+		}
+		if comment.CreatedAt.Before(ours.CreatedAt.Add(-ClaimWindow)) {
+			continue
+		}
+		if comment.CreatedAt.Before(winner.CreatedAt) || (comment.CreatedAt.Equal(winner.CreatedAt) && comment.ID < winner.ID) {
+			winner = comment
+		}
+	}
+	return winner.ID
+}
+
+// sortTicketComments orders comments oldest first
+func sortTicketComments(comments []TicketComment) {
+	sort.SliceStable(comments, func(i, j int) bool { return comments[i].CreatedAt.Before(comments[j].CreatedAt) })
 }
 
 // IterationProgress contains information about what was accomplished in an iteration
@@ -904,6 +2117,7 @@ type IterationProgress struct {
 	StepsCompleted []string
 	CommitMessage  string
 	FilesChanged   []string
+	AddedTasks     []string // Titles of PRD tasks added during Workflow 2 (e.g. by self-improvement)
 }
 
 // ProgressCallback is called after each iteration completes
@@ -911,35 +2125,109 @@ type ProgressCallback func(progress IterationProgress) error
 
 // getLastCommitMessage gets the last git commit message
 func getLastCommitMessage() string {
-	cmd := exec.Command("git", "log", "-1", "--pretty=%B")
-	output, err := cmd.Output()
+	output, err := gitOutput("log", "-1", "--pretty=%B")
 	if err != nil {
 		return ""
 	}
 	return strings.TrimSpace(string(output))
 }
 
+// getRecentCommitMessages returns the last n commits as "- <hash> <full message>" entries, newest first
+func getRecentCommitMessages(n int) string {
+	output, err := gitOutput("log", fmt.Sprintf("-%d", n), "--pretty=format:- %h %B%x00")
+	if err != nil {
+		return ""
+	}
+	var messages []string
+	for _, message := range splitNUL(output) {
+		if message = strings.TrimSpace(message); message != "" {
+			messages = append(messages, message)
+		}
+	}
+	return strings.Join(messages, "\n\n")
+}
+
 // getChangedFiles gets list of files changed in the last commit
 func getChangedFiles() []string {
-	cmd := exec.Command("git", "diff", "--name-only", "HEAD~1", "HEAD")
-	output, err := cmd.Output()
+	output, err := gitOutput("diff", "--name-only", "-z", "HEAD~1", "HEAD")
 	if err != nil {
 		// If there's no previous commit, check unstaged changes
-		cmd = exec.Command("git", "diff", "--name-only")
-		output, err = cmd.Output()
+		output, err = gitOutput("diff", "--name-only", "-z")
 		if err != nil {
 			return []string{}
 		}
 	}
-	
-	files := strings.Split(strings.TrimSpace(string(output)), "\n")
-	var result []string
-	for _, file := range files {
-		if file != "" {
-			result = append(result, file)
+	return splitNUL(output)
+}
+
+// maxDiffstatFiles is the number of files listed individually in a diffstat summary
+const maxDiffstatFiles = 15
+
+// getDiffstatSummary returns a markdown summary of the changes between baseBranch and branchName:
+// the most-changed files with insertion/deletion counts, plus a total line.
+func getDiffstatSummary(baseBranch, branchName string) (string, error) {
+	output, err := gitOutput("diff", "--numstat", "-z", baseBranch+"..."+branchName)
+	if err != nil {
+		return "", fmt.Errorf("failed to compute diffstat: %v", err)
+	}
+
+	type fileStat struct {
+		path    string
+		added   int
+		deleted int
+		binary  bool
+	}
+
+	var stats []fileStat
+	totalAdded, totalDeleted := 0, 0
+	// With -z, each entry is "added\tdeleted\tpath"; a rename leaves the path empty and is followed by
+	// the old and new paths as separate entries
+	entries := splitNUL(output)
+	for i := 0; i < len(entries); i++ {
+		parts := strings.SplitN(entries[i], "\t", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		if parts[2] == "" && i+2 < len(entries) {
+			parts[2] = entries[i+2]
+			i += 2
+		}
+		stat := fileStat{path: parts[2]}
+		// Binary files are reported as "-\t-\tpath"
+		if parts[0] == "-" || parts[1] == "-" {
+			stat.binary = true
+		} else {
+			fmt.Sscanf(parts[0], "%d", &stat.added)
+			fmt.Sscanf(parts[1], "%d", &stat.deleted)
+		}
+		totalAdded += stat.added
+		totalDeleted += stat.deleted
+		stats = append(stats, stat)
+	}
+
+	if len(stats) == 0 {
+		return "", nil
+	}
+
+	// Sort by total lines changed (largest first)
+	sort.SliceStable(stats, func(i, j int) bool {
+		return stats[i].added+stats[i].deleted > stats[j].added+stats[j].deleted
+	})
+
+	var lines []string
+	lines = append(lines, fmt.Sprintf("**Changes:** %d file(s) changed, +%d / -%d", len(stats), totalAdded, totalDeleted))
+	for i, stat := range stats {
+		if i >= maxDiffstatFiles {
+			lines = append(lines, fmt.Sprintf("- ... and %d more file(s)", len(stats)-maxDiffstatFiles))
+			break
+		}
+		if stat.binary {
+			lines = append(lines, fmt.Sprintf("- %s (binary)", markdownPath(stat.path)))
+		} else {
+			lines = append(lines, fmt.Sprintf("- %s (+%d / -%d)", markdownPath(stat.path), stat.added, stat.deleted))
 		}
 	}
-	return result
+	return strings.Join(lines, "\n"), nil
 }
 
 // runRalphLoop runs the main ralph loop with the given iterations
@@ -1037,14 +2325,29 @@ func listPendingTickets(configFile string) error {
 		return fmt.Errorf("failed to load config: %v", err)
 	}
 
+	// Jira: list the tickets manager mode would consider
+	if strings.EqualFold(config.Tracker, TrackerJira) {
+		tickets, err := NewJiraClient(config).fetchTodoTickets(config.Project)
+		if err != nil {
+			return fmt.Errorf("failed to fetch tickets: %v", err)
+		}
+		printTickets(tickets, "Todo")
+		return nil
+	}
+
 	// Initialize Linear client
 	client := NewLinearClient(config.Token)
 
+	// Resolve a project slug/name to its UUID (or fail early with the list of projects)
+	if err := resolveLinearProject(client, config); err != nil {
+		return err
+	}
+
 	// First, try to list projects to help find the correct UUID if needed
-	fmt.Println("ℹ️  Listing available projects to help find the correct project ID...")
+	statusf("ℹ️  Listing available projects to help find the correct project ID...\n")
 	projects, err := client.listProjects()
 	if err != nil {
-		fmt.Printf("⚠️  Warning: Could not list projects: %v\n", err)
+		statusf("⚠️  Warning: Could not list projects: %v\n", err)
 	} else if len(projects) > 0 {
 		fmt.Println("\nAvailable projects:")
 		for _, project := range projects {
@@ -1052,7 +2355,7 @@ func listPendingTickets(configFile string) error {
 		}
 		fmt.Println()
 	} else {
-		fmt.Println("⚠️  No projects found in workspace.")
+		statusf("⚠️  No projects found in workspace.\n")
 		fmt.Println()
 	}
 
@@ -1124,7 +2427,7 @@ func listPendingTickets(configFile string) error {
 
 	var result struct {
 		Issues struct {
-			Nodes []LinearIssue `json:"nodes"`
+			Nodes []Issue `json:"nodes"`
 		} `json:"issues"`
 	}
 
@@ -1143,24 +2446,53 @@ func listPendingTickets(configFile string) error {
 		}
 	}
 
+	printTickets(tickets, "all states")
+	return nil
+}
+
+// ticketPriorityName returns the name of a Linear priority number (1 = Urgent ... 4 = Low, 0 = none)
+func ticketPriorityName(priority float64) string {
+	switch int(priority) {
+	case 1:
+		return "Urgent"
+	case 2:
+		return "High"
+	case 3:
+		return "Medium"
+	case 4:
+		return "Low"
+	}
+	return "No priority"
+}
+
+// printQueuePreview lists the first n tickets in selection order, so the operator sees what the manager will work on next
+func printQueuePreview(tickets []Issue, n int) {
+	if n <= 0 || len(tickets) == 0 {
+		return
+	}
+	if n > len(tickets) {
+		n = len(tickets)
+	}
+	statusf("📋 Queue (next %d of %d Todo ticket(s)):\n", n, len(tickets))
+	for i, ticket := range tickets[:n] {
+		details := ticketPriorityName(ticket.Priority)
+		if ticket.Estimate != nil {
+			details += fmt.Sprintf(", estimate %.0f", *ticket.Estimate)
+		}
+		fmt.Printf("   %d. %s (%s)\n", i+1, ticketDisplayName(&ticket), details)
+	}
+}
+
+// printTickets prints the details of each ticket for --tickets
+func printTickets(tickets []Issue, scope string) {
 	if len(tickets) == 0 {
-		fmt.Println("✅ No tickets found in this project.")
-		return nil
+		statusf("✅ No tickets found in this project.\n")
+		return
 	}
 
-	fmt.Printf("📋 Found %d ticket(s) in project (all states):\n\n", len(tickets))
+	statusf("📋 Found %d ticket(s) in project (%s):\n\n", len(tickets), scope)
 	for i, ticket := range tickets {
-		priorityName := "No priority"
-		switch int(ticket.Priority) {
-		case 1:
-			priorityName = "Urgent"
-		case 2:
-			priorityName = "High"
-		case 3:
-			priorityName = "Medium"
-		case 4:
-			priorityName = "Low"
-		}
+		priorityName := ticketPriorityName(ticket.Priority)
 
 		fmt.Printf("%d. %s\n", i+1, ticket.Title)
 		fmt.Printf("   Identifier: %s\n", ticket.Identifier)
@@ -1219,24 +2551,98 @@ func listPendingTickets(configFile string) error {
 		fmt.Println()
 	}
 
+}
+
+// managerDryRun prints the ticket manager mode would pick next, with its branch, base branch, budget and PRD input.
+// It makes no changes: no ticket transitions, comments, branches or Claude calls.
+func managerDryRun(client IssueProvider, config *LinearConfig, iterations int) error {
+	statusf("🧪 Dry run: no tickets, branches or files will be changed\n")
+
+	tickets, err := client.fetchTodoTickets(config.Project)
+	if err != nil {
+		return fmt.Errorf("failed to fetch tickets: %v", err)
+	}
+	tickets = orderTickets(excludeEscalatedTickets(tickets, config), config)
+	tickets, teamless := splitTeamlessTickets(tickets)
+	for _, ticket := range teamless {
+		statusf("⚠️  Would skip ticket %s: it has no team\n", ticket.Title)
+	}
+	tickets, waiting := splitWaitingSubissues(tickets, config)
+	for _, ticket := range waiting {
+		statusf("ℹ️  Would skip ticket %s: parent %s is not Done\n", ticket.Title, ticket.Parent.Identifier)
+	}
+	if len(tickets) == 0 {
+		statusf("ℹ️  No Todo tickets found. Manager mode would sleep and check again.\n")
+		return nil
+	}
+
+	issue := &tickets[0]
+	branchName := ticketBranchName(client, issue, config)
+	baseBranch := ticketBaseBranch(issue, config.BaseBranch)
+	if baseBranch == "" {
+		baseBranch = "(auto-detect main/master)"
+	}
+	budget, source := ticketIterationBudget(config, issue, iterations)
+
+	statusf("📋 Would select: %s (Priority: %.0f)\n", issue.Title, issue.Priority)
+	if issue.URL != "" {
+		fmt.Printf("   URL:         %s\n", issue.URL)
+	}
+	fmt.Printf("   Branch:      %s\n", branchName)
+	fmt.Printf("   Base branch: %s\n", baseBranch)
+	fmt.Printf("   Iterations:  %d (from %s)\n", budget, source)
+	fmt.Println()
+	fmt.Println("PRD description that would be sent to Claude:")
+	fmt.Println("---")
+	fmt.Printf("%s\n\n%s\n", issue.Title, issue.Description)
+	fmt.Println("---")
+
+	if len(tickets) > 1 {
+		statusf("\nℹ️  %d other Todo ticket(s) queued after this one (or picked if it is claimed by another instance)\n", len(tickets)-1)
+	}
 	return nil
 }
 
-// runManagerMode is the main manager loop
-func runManagerMode(configFile string, iterations int) error {
+// runManagerMode is the main manager loop. A non-empty onEmpty (--on-empty) overrides on_empty from the config,
+// and a non-empty metricsFile (--metrics-file) is kept up to date with the session's Prometheus metrics.
+func runManagerMode(configFile string, iterations int, dryRun bool, keepGoing bool, once bool, onEmpty string, metricsFile string) error {
 	// Load Linear config
 	config, err := loadLinearConfig(configFile)
 	if err != nil {
-		return fmt.Errorf("failed to load config: %v", err)
+		return &configError{fmt.Errorf("failed to load config: %v", err)}
+	}
+	if onEmpty != "" {
+		config.OnEmpty = onEmpty
+	} else if once && config.OnEmpty == "" {
+		// A one-shot run has nothing to wait for
+		config.OnEmpty = OnEmptyExit
+	}
+	exitAfterEmptyPolls, err := parseOnEmpty(config.OnEmpty)
+	if err != nil {
+		return &configError{err}
 	}
 
-	// Validate git setup (remote and GitHub CLI)
-	if err := validateGitSetup(); err != nil {
-		return fmt.Errorf("git setup validation failed: %v", err)
+	// Validate git setup (remote and PR provider)
+	prCreator, err := validateGitSetup(config)
+	if err != nil {
+		return &configError{fmt.Errorf("git setup validation failed: %v", err)}
 	}
 
-	// Initialize Linear client
-	client := NewLinearClient(config.Token)
+	// Initialize the issue tracker client (Linear or Jira)
+	client, err := newIssueProvider(config)
+	if err != nil {
+		return &configError{fmt.Errorf("invalid project in config: %v", err)}
+	}
+
+	// Catch a broken escalate_user now rather than when an escalation silently notifies no one
+	if err := client.verifyUser(config.EscalateUser); err != nil {
+		statusf("⚠️  Warning: escalate_user %q could not be verified in %s: %v\n", config.EscalateUser, client.Name(), err)
+		fmt.Println("   Escalation comments may not notify anyone until escalate_user is fixed.")
+	}
+
+	if dryRun {
+		return managerDryRun(client, config, iterations)
+	}
 
 	// Check for resume state
 	managerState, err := loadManagerState()
@@ -1248,19 +2654,20 @@ func runManagerMode(configFile string, iterations int) error {
 		// Verify ticket still exists and is in "In Progress"
 		valid, err := client.verifyIssueState(managerState.IssueID, "In Progress")
 		if err != nil {
-			fmt.Printf("⚠️  Error verifying resume state: %v\n", err)
+			statusf("⚠️  Error verifying resume state: %v\n", err)
 			clearManagerState()
 			managerState = nil
 		} else if !valid {
-			fmt.Printf("⚠️  Resume state invalid (ticket not in 'In Progress'), starting fresh\n")
+			statusf("⚠️  Resume state invalid (ticket not in 'In Progress'), starting fresh\n")
 			clearManagerState()
 			managerState = nil
 		} else {
 			// Resume from existing ticket
-			fmt.Printf("🔄 Resuming from ticket %s on branch %s\n", managerState.IssueID, managerState.BranchName)
-			// Checkout the branch
-			if err := createGitBranch(managerState.BranchName, config.BaseBranch); err != nil {
-				fmt.Printf("⚠️  Failed to checkout branch %s: %v\n", managerState.BranchName, err)
+			statusf("🔄 Resuming from ticket %s on branch %s\n", managerState.IssueID, managerState.BranchName)
+			// Checkout the branch (restoring it from origin first if the local branch was deleted)
+			baseBranch := restoreMissingBranch(client, managerState.IssueID, managerState.BranchName, config.BaseBranch)
+			if err := createGitBranch(managerState.BranchName, baseBranch); err != nil {
+				statusf("⚠️  Failed to checkout branch %s: %v\n", managerState.BranchName, err)
 				clearManagerState()
 				managerState = nil
 			}
@@ -1269,113 +2676,267 @@ func runManagerMode(configFile string, iterations int) error {
 
 	// If no saved state or saved state is invalid, check current branch for recovery
 	if managerState == nil || managerState.IssueID == "" {
-		branchState, err := detectBranchBasedRecovery(client)
+		branchState, err := detectBranchBasedRecovery(client, config)
 		if err != nil {
 			// Log error but don't fail - continue to normal flow
-			fmt.Printf("⚠️  Warning: Error detecting branch-based recovery: %v\n", err)
+			statusf("⚠️  Warning: Error detecting branch-based recovery: %v\n", err)
 		} else if branchState != nil {
 			// Found valid recovery state from branch
-			fmt.Printf("🔄 Detected in-progress ticket from branch %s, resuming\n", branchState.BranchName)
+			statusf("🔄 Detected in-progress ticket from branch %s, resuming\n", branchState.BranchName)
 			managerState = branchState
 			// Save the state so it persists
 			if err := saveManagerState(managerState); err != nil {
-				fmt.Printf("⚠️  Warning: Failed to save manager state: %v\n", err)
+				statusf("⚠️  Warning: Failed to save manager state: %v\n", err)
 			}
 			// Ensure we're on the branch (we should already be, but verify)
 			if err := createGitBranch(managerState.BranchName, config.BaseBranch); err != nil {
-				fmt.Printf("⚠️  Failed to checkout branch %s: %v\n", managerState.BranchName, err)
+				statusf("⚠️  Failed to checkout branch %s: %v\n", managerState.BranchName, err)
 				managerState = nil
 			}
 		}
 	}
 
+	// Iterations used across all tickets this session (for max_total_iterations)
+	totalIterations := 0
+
+	// Session counters for --metrics-file, rewritten after each iteration and ticket
+	metrics := &ManagerMetrics{File: metricsFile, Started: clock.Now()}
+	metrics.write()
+	defer func() {
+		metrics.CurrentTicket = ""
+		metrics.write()
+	}()
+
+	// Team-less tickets already reported this session
+	warnedTeamless := make(map[string]bool)
+	warnedWaiting := make(map[string]bool)
+
+	// Ticket outcomes for the end-of-session summary (--keep-going)
+	var succeededTickets, failedTickets []string
+	if keepGoing {
+		defer func() { printManagerSummary(succeededTickets, failedTickets) }()
+	}
+
+	// Circuit breaker for --keep-going: a run of failures usually means a systemic problem (tracker API, Claude),
+	// not bad tickets, so stop instead of failing and escalating the whole backlog
+	maxConsecutiveFailures := config.MaxConsecutiveFailures
+	if maxConsecutiveFailures == 0 {
+		maxConsecutiveFailures = DefaultMaxConsecutiveFailures
+	}
+	consecutiveFailures := 0
+
+	// List the upcoming tickets (queue_preview) at session start and after each completed ticket
+	showQueue := true
+
+	// Polls in a row that found nothing to work on (on_empty)
+	poller := &emptyPoller{exitAfter: exitAfterEmptyPolls, onEmpty: config.OnEmpty}
+
+	// ticketFailed ends the session on a ticket failure, or with --keep-going records it and lets the loop move on.
+	// The caller has already escalated the ticket.
+	ticketFailed := func(issue *Issue, branchName string, err error) error {
+		metrics.Failed++
+		metrics.CurrentTicket = ""
+		metrics.write()
+		if !keepGoing || once {
+			return err
+		}
+		statusf("❌ Ticket %s failed: %v (continuing with --keep-going)\n", ticketDisplayName(issue), err)
+		failedTickets = append(failedTickets, fmt.Sprintf("%s: %v", ticketDisplayName(issue), err))
+		stashFailedTicketWork(branchName)
+		clearManagerState()
+		managerState = nil
+
+		consecutiveFailures++
+		if consecutiveFailures >= maxConsecutiveFailures {
+			return fmt.Errorf("too many consecutive failures (%d tickets in a row), aborting; last error: %v", consecutiveFailures, err)
+		}
+		return nil
+	}
+
 	// Main loop
 	for {
-		var issue *LinearIssue
+		var issue *Issue
 		var branchName string
+		var batch []*Issue // Tickets worked together with issue on its branch (batch_by)
+
+		if config.MaxTotalIterations > 0 && totalIterations >= config.MaxTotalIterations {
+			statusf("ℹ️  Session iteration cap reached (max_total_iterations = %d). Stopping manager.\n", config.MaxTotalIterations)
+			return nil
+		}
 
 		if managerState != nil && managerState.IssueID != "" {
 			// Resuming - fetch the issue
-			query := `
-				query($issueId: String!) {
-					issue(id: $issueId) {
-						id
-						title
-						description
-						priority
-						state {
-							name
-							id
-						}
-						team {
-							id
-						}
-					}
-				}
-			`
-
-			variables := map[string]interface{}{
-				"issueId": managerState.IssueID,
-			}
-
-			data, err := client.executeGraphQL(query, variables)
+			resumeIssue, err := client.getIssue(managerState.IssueID)
 			if err != nil {
 				return fmt.Errorf("failed to fetch resume issue: %v", err)
 			}
 
-			var result struct {
-				Issue LinearIssue `json:"issue"`
-			}
-
-			if err := json.Unmarshal(data, &result); err != nil {
-				return fmt.Errorf("failed to parse issue: %v", err)
-			}
-
-			if result.Issue.ID == "" {
-				fmt.Printf("⚠️  Resume issue not found, starting fresh\n")
+			if resumeIssue == nil {
+				statusf("⚠️  Resume issue not found, starting fresh\n")
 				clearManagerState()
 				managerState = nil
 				continue
 			}
 
-			issue = &result.Issue
+			issue = resumeIssue
+			metrics.CurrentTicket = ticketDisplayName(issue)
+			metrics.write()
 			branchName = managerState.BranchName
+			for _, id := range managerState.BatchIssueIDs {
+				batchIssue, err := client.getIssue(id)
+				if err != nil || batchIssue == nil {
+					statusf("⚠️  Warning: batched ticket %s could not be fetched, leaving it out of the batch: %v\n", id, err)
+					continue
+				}
+				batch = append(batch, batchIssue)
+			}
 		} else {
 			// Fetch Todo tickets
 			tickets, err := client.fetchTodoTickets(config.Project)
 			if err != nil {
 				return fmt.Errorf("failed to fetch tickets: %v", err)
 			}
+			tickets = orderTickets(excludeEscalatedTickets(tickets, config), config)
+
+			// Sub-issues wait for their parent when wait_for_parent is set
+			tickets, waiting := splitWaitingSubissues(tickets, config)
+			for _, ticket := range waiting {
+				if warnedWaiting[ticket.ID] {
+					continue
+				}
+				warnedWaiting[ticket.ID] = true
+				statusf("ℹ️  Skipping ticket %s: parent %s is not Done (%s)\n", ticket.Title, ticket.Parent.Identifier, ticket.Parent.State.Name)
+			}
+
+			// Skip tickets without a team; warn (and optionally escalate) once per ticket per session
+			tickets, teamless := splitTeamlessTickets(tickets)
+			for _, ticket := range teamless {
+				if warnedTeamless[ticket.ID] {
+					continue
+				}
+				warnedTeamless[ticket.ID] = true
+				statusf("⚠️  Warning: skipping ticket %s: it has no team, so its workflow state can't be changed\n", ticket.Title)
+				if config.EscalateTeamless {
+					comment := "⚠️  Ralph skipped this ticket because it has no team, so its workflow state can't be updated. Move it to a team to make it automatable."
+					if err := client.addTicketComment(ticket.ID, comment, []string{config.EscalateUser}); err != nil {
+						statusf("⚠️  Warning: failed to add comment: %v\n", err)
+					}
+				}
+			}
 
 			if len(tickets) == 0 {
-				fmt.Println("ℹ️  No Todo tickets found. Sleeping for 1 minute and checking again...")
-				time.Sleep(1 * time.Minute)
+				if poller.idle("No Todo tickets found") {
+					return nil
+				}
 				continue
 			}
 
-			// Select highest priority ticket (first one, already sorted)
-			issue = &tickets[0]
-			fmt.Printf("📋 Selected ticket: %s (Priority: %.0f)\n", issue.Title, issue.Priority)
+			if showQueue {
+				printQueuePreview(tickets, config.QueuePreview)
+				showQueue = false
+			}
+
+			// Claim the first ticket in selection order (priority or board) that no other manager instance has taken
+			issue = nil
+			for i := range tickets {
+				claimed, err := claimTicket(client, &tickets[i])
+				if err != nil {
+					statusf("⚠️  Warning: failed to claim ticket %s: %v\n", tickets[i].Title, err)
+					continue
+				}
+				if claimed {
+					issue = &tickets[i]
+					break
+				}
+				statusf("ℹ️  Ticket %s was claimed by another instance, trying next candidate\n", tickets[i].Title)
+			}
+			if issue == nil {
+				if poller.idle("No Todo ticket could be claimed") {
+					return nil
+				}
+				continue
+			}
+			poller.polls = 0
+			headerf("📋 Selected ticket: %s (Priority: %.0f)\n", issue.Title, issue.Priority)
+			metrics.CurrentTicket = ticketDisplayName(issue)
+			metrics.write()
+
+			// A "base:<branch>" label overrides the configured base branch for this ticket
+			ticketBase := ticketBaseBranch(issue, config.BaseBranch)
+			if ticketBase != config.BaseBranch {
+				if !gitBranchExists(ticketBase) {
+					errorComment := fmt.Sprintf("❌ Base branch `%s` (from ticket label `%s%s`) does not exist locally or on origin.\n\nPlease fix the label or create the branch, then move the ticket back to Todo.", ticketBase, BaseBranchLabelPrefix, ticketBase)
+					usernames := []string{config.EscalateUser}
+					if err := client.addTicketComment(issue.ID, errorComment, usernames); err != nil {
+						statusf("⚠️  Warning: failed to add error comment: %v\n", err)
+					}
+					escalateTicket(client, config, issue)
+					if err := ticketFailed(issue, "", fmt.Errorf("base branch %s for ticket %s does not exist", ticketBase, issue.Title)); err != nil {
+						return err
+					}
+					continue
+				}
+				statusf("ℹ️  Using base branch %s from ticket label\n", ticketBase)
+			}
 
 			// Create git branch
-			issueSlug := slugify(issue.Title)
-			branchName = fmt.Sprintf("linear/%s-%s", issue.ID, issueSlug)
-			if err := createGitBranch(branchName, config.BaseBranch); err != nil {
+			branchName = ticketBranchName(client, issue, config)
+			if err := createGitBranch(branchName, ticketBase); err != nil {
+				if keepGoing {
+					// Escalate rather than just release, so the ticket isn't picked again straight away
+					escalateTicket(client, config, issue)
+					if err := ticketFailed(issue, "", fmt.Errorf("failed to create git branch: %v", err)); err != nil {
+						return err
+					}
+					continue
+				}
+				// Release the claim so the ticket can be picked up again
+				if err := client.updateTicketStatus(issue.ID, issue.Team.ID, "Todo"); err != nil {
+					statusf("⚠️  Warning: failed to update ticket status: %v\n", err)
+				}
 				return fmt.Errorf("failed to create git branch: %v", err)
 			}
 
+			// Claim the rest of the ticket's group (batch_by) to work on the same branch
+			for _, candidate := range batchCandidates(issue, tickets, config) {
+				claimed, err := claimTicket(client, candidate)
+				if err != nil {
+					statusf("⚠️  Warning: failed to claim ticket %s for the batch: %v\n", candidate.Title, err)
+					continue
+				}
+				if claimed {
+					batch = append(batch, candidate)
+				}
+			}
+			if len(batch) > 0 {
+				statusf("📦 Batching %d related ticket(s) on branch %s:\n", len(batch), branchName)
+				for _, ticket := range batch {
+					fmt.Printf("   - %s\n", ticketDisplayName(ticket))
+				}
+			}
+
 			// Create PRD from ticket first (so we can include it in the comment)
 			prdDescription := fmt.Sprintf("%s\n\n%s", issue.Title, issue.Description)
+			if len(batch) > 0 {
+				prdDescription = batchPRDDescription(issue, batch)
+			}
 			if err := createPRD(prdDescription); err != nil {
 				// Error creating PRD - escalate
 				errorComment := fmt.Sprintf("❌ Error creating PRD for ticket:\n\n**Error:** %v\n**Branch:** `%s`", err, branchName)
 				usernames := []string{config.EscalateUser}
 				if err := client.addTicketComment(issue.ID, errorComment, usernames); err != nil {
-					fmt.Printf("⚠️  Warning: failed to add error comment: %v\n", err)
+					statusf("⚠️  Warning: failed to add error comment: %v\n", err)
 				}
 
+				// Move the ticket back to Todo, labeled so it is not picked again immediately
+				escalateTicket(client, config, issue)
+				escalateBatch(client, config, issue, batch)
+
 				clearManagerState()
-				return fmt.Errorf("failed to create PRD: %v", err)
+				if err := ticketFailed(issue, branchName, fmt.Errorf("failed to create PRD: %v", err)); err != nil {
+					return err
+				}
+				continue
 			}
 
 			// Read PRD content to include in comment
@@ -1396,7 +2957,13 @@ func runManagerMode(configFile string, iterations int) error {
 			comment := strings.Join(commentParts, "\n")
 			usernames := []string{config.EscalateUser}
 			if err := client.addTicketComment(issue.ID, comment, usernames); err != nil {
-				fmt.Printf("⚠️  Warning: failed to add comment to ticket: %v\n", err)
+				statusf("⚠️  Warning: failed to add comment to ticket: %v\n", err)
+			}
+			for _, ticket := range batch {
+				batchComment := fmt.Sprintf("Starting work on branch `%s`, batched with %s. Progress is posted on %s.", branchName, ticketDisplayName(issue), ticketDisplayName(issue))
+				if err := client.addTicketComment(ticket.ID, batchComment, nil); err != nil {
+					statusf("⚠️  Warning: failed to add comment to ticket: %v\n", err)
+				}
 			}
 
 			// Save manager state
@@ -1404,99 +2971,156 @@ func runManagerMode(configFile string, iterations int) error {
 				IssueID:    issue.ID,
 				BranchName: branchName,
 				Iteration:  1,
+				Phase:      ManagerPhaseWorking,
+			}
+			for _, ticket := range batch {
+				managerState.BatchIssueIDs = append(managerState.BatchIssueIDs, ticket.ID)
 			}
 			if err := saveManagerState(managerState); err != nil {
 				return fmt.Errorf("failed to save manager state: %v", err)
 			}
 
-			// Update ticket to "In Progress"
-			if err := client.updateTicketStatus(issue.ID, issue.Team.ID, "In Progress"); err != nil {
-				return fmt.Errorf("failed to update ticket status: %v", err)
-			}
 		}
 
-		// Create progress callback for Linear updates
-		progressCallback := func(progress IterationProgress) error {
-			var commentParts []string
-			commentParts = append(commentParts, fmt.Sprintf("**Iteration %d/%d completed**", progress.Iteration, progress.MaxIterations))
-
-			if len(progress.StepsCompleted) > 0 {
-				commentParts = append(commentParts, "\n**Steps completed:**")
-				for _, step := range progress.StepsCompleted {
-					commentParts = append(commentParts, fmt.Sprintf("- ✅ %s", step))
+		// A ticket whose work already finished before a restart skips straight to the pull request or status update
+		if managerState.Phase == "" || managerState.Phase == ManagerPhaseWorking {
+			// Work out this ticket's iteration budget
+			ticketIterations, limitSource := ticketIterationBudget(config, issue, iterations)
+			if config.MaxTotalIterations > 0 {
+				if remaining := config.MaxTotalIterations - totalIterations; remaining < ticketIterations {
+					ticketIterations = remaining
+					limitSource = "session cap (max_total_iterations)"
 				}
 			}
+			statusf("ℹ️  Iteration budget for this ticket: %d (%s)\n", ticketIterations, limitSource)
+
+			// Create progress callback for Linear updates
+			iterationsUsed := 0
+			progressCallback := func(progress IterationProgress) error {
+				iterationsUsed = progress.Iteration
+				metrics.Iterations = totalIterations + iterationsUsed
+				metrics.write()
+				var commentParts []string
+				commentParts = append(commentParts, fmt.Sprintf("**Iteration %d/%d completed**", progress.Iteration, progress.MaxIterations))
+
+				if len(progress.StepsCompleted) > 0 {
+					commentParts = append(commentParts, "\n**Steps completed:**")
+					for _, step := range progress.StepsCompleted {
+						commentParts = append(commentParts, fmt.Sprintf("- ✅ %s", step))
+					}
+				}
 
-			if progress.CommitMessage != "" {
-				commentParts = append(commentParts, fmt.Sprintf("\n**Commit:** `%s`", progress.CommitMessage))
-			}
+				if progress.CommitMessage != "" {
+					commentParts = append(commentParts, fmt.Sprintf("\n**Commit:** `%s`", progress.CommitMessage))
+				}
 
-			if len(progress.FilesChanged) > 0 {
-				commentParts = append(commentParts, fmt.Sprintf("\n**Files changed:** %d", len(progress.FilesChanged)))
-				if len(progress.FilesChanged) <= 10 {
-					// Show all files if 10 or fewer
-					for _, file := range progress.FilesChanged {
-						commentParts = append(commentParts, fmt.Sprintf("- `%s`", file))
+				if len(progress.FilesChanged) > 0 {
+					commentParts = append(commentParts, fmt.Sprintf("\n**Files changed:** %d", len(progress.FilesChanged)))
+					if len(progress.FilesChanged) <= 10 {
+						// Show all files if 10 or fewer
+						for _, file := range progress.FilesChanged {
+							commentParts = append(commentParts, fmt.Sprintf("- %s", markdownPath(file)))
+						}
+					} else {
+						// Show first 10 files if more than 10
+						for _, file := range progress.FilesChanged[:10] {
+							commentParts = append(commentParts, fmt.Sprintf("- %s", markdownPath(file)))
+						}
+						commentParts = append(commentParts, fmt.Sprintf("- ... and %d more", len(progress.FilesChanged)-10))
 					}
-				} else {
-					// Show first 10 files if more than 10
-					for _, file := range progress.FilesChanged[:10] {
-						commentParts = append(commentParts, fmt.Sprintf("- `%s`", file))
+				}
+
+				if len(progress.AddedTasks) > 0 {
+					commentParts = append(commentParts, "\n**Tasks added to PRD:**")
+					for _, task := range progress.AddedTasks {
+						commentParts = append(commentParts, fmt.Sprintf("- %s", task))
 					}
-					commentParts = append(commentParts, fmt.Sprintf("- ... and %d more", len(progress.FilesChanged)-10))
 				}
-			}
 
-			comment := strings.Join(commentParts, "\n")
-			return client.addTicketComment(issue.ID, comment, nil)
-		}
+				comment := strings.Join(commentParts, "\n")
+				if config.SingleProgressComment {
+					return postProgressComment(client, issue.ID, managerState, comment)
+				}
+				return client.addTicketComment(issue.ID, comment, nil)
+			}
 
-		// Run ralph loop
-		completed, err := runRalphLoop(iterations, progressCallback)
-		if err != nil {
-			// Error during ralph execution - escalate
-			errorComment := fmt.Sprintf("❌ Error during ralph execution:\n\n**Error:** %v\n**Branch:** `%s`", err, branchName)
-			usernames := []string{config.EscalateUser}
-			if err := client.addTicketComment(issue.ID, errorComment, usernames); err != nil {
-				fmt.Printf("⚠️  Warning: failed to add error comment: %v\n", err)
+			// With require_plan_approval, each plan waits for a human on the ticket before implementation
+			if config.RequirePlanApproval {
+				approvalIssue := issue
+				planApprovalGate = func(iteration int) (bool, error) {
+					return awaitPlanApproval(client, config, approvalIssue, iteration)
+				}
 			}
 
-			// Update ticket back to "Todo"
-			if err := client.updateTicketStatus(issue.ID, issue.Team.ID, "Todo"); err != nil {
-				fmt.Printf("⚠️  Warning: failed to update ticket status: %v\n", err)
+			// Run ralph loop; its commits must stay on the ticket branch, and with manager_scope_lock
+			// self-improvement can't add tasks beyond the ticket
+			expectedCommitBranch = branchName
+			scopeLocked = ralphConfig.ManagerScopeLock
+			iterationTicket = issue.Identifier
+			completed, err := runRalphLoop(ticketIterations, progressCallback)
+			planApprovalGate = nil
+			expectedCommitBranch = ""
+			scopeLocked = false
+			iterationTicket = ""
+			if iterationsUsed == 0 {
+				iterationsUsed = 1
 			}
+			totalIterations += iterationsUsed
+			metrics.Iterations = totalIterations
+			if err != nil {
+				// Error during ralph execution - escalate
+				errorComment := fmt.Sprintf("❌ Error during ralph execution:\n\n**Error:** %v\n**Branch:** `%s`", err, branchName)
+				usernames := []string{config.EscalateUser}
+				if err := client.addTicketComment(issue.ID, errorComment, usernames); err != nil {
+					statusf("⚠️  Warning: failed to add error comment: %v\n", err)
+				}
 
-			clearManagerState()
-			return fmt.Errorf("ralph execution failed: %v", err)
-		}
+				// Move the ticket back to Todo, labeled so it is not picked again immediately
+				escalateTicket(client, config, issue)
+				escalateBatch(client, config, issue, batch)
 
-		if !completed {
-			// Iteration limit reached - escalate
-			errorComment := fmt.Sprintf("⚠️  Iteration limit (%d) reached but PRD not complete.\n\n**Branch:** `%s`\n\nPlease review and continue manually.", iterations, branchName)
-			usernames := []string{config.EscalateUser}
-			if err := client.addTicketComment(issue.ID, errorComment, usernames); err != nil {
-				fmt.Printf("⚠️  Warning: failed to add error comment: %v\n", err)
+				clearManagerState()
+				if err := ticketFailed(issue, branchName, fmt.Errorf("ralph execution failed: %v", err)); err != nil {
+					return err
+				}
+				continue
 			}
 
-			// Update ticket back to "Todo"
-			if err := client.updateTicketStatus(issue.ID, issue.Team.ID, "Todo"); err != nil {
-				fmt.Printf("⚠️  Warning: failed to update ticket status: %v\n", err)
+			if !completed {
+				// Iteration limit reached - escalate
+				errorComment := fmt.Sprintf("⚠️  Iteration limit (%d, from %s) reached but PRD not complete.\n\n**Branch:** `%s`\n\nPlease review and continue manually.", ticketIterations, limitSource, branchName)
+				usernames := []string{config.EscalateUser}
+				if err := client.addTicketComment(issue.ID, errorComment, usernames); err != nil {
+					statusf("⚠️  Warning: failed to add error comment: %v\n", err)
+				}
+
+				// Move the ticket back to Todo, labeled so it is not picked again immediately
+				escalateTicket(client, config, issue)
+				escalateBatch(client, config, issue, batch)
+
+				clearManagerState()
+				if err := ticketFailed(issue, branchName, fmt.Errorf("iteration limit reached without completion")); err != nil {
+					return err
+				}
+				continue
 			}
 
-			clearManagerState()
-			return fmt.Errorf("iteration limit reached without completion")
+			managerState.Phase = ManagerPhaseCompleted
+			if err := saveManagerState(managerState); err != nil {
+				statusf("⚠️  Warning: failed to save manager state: %v\n", err)
+			}
+		} else {
+			statusf("⏭️  Work on ticket %s already completed (phase %s), skipping the Ralph loop\n", ticketDisplayName(issue), managerState.Phase)
 		}
 
 		// Success! Create pull request
-		baseBranch := config.BaseBranch
+		baseBranch := ticketBaseBranch(issue, config.BaseBranch)
 		if baseBranch == "" {
 			// Try to detect default branch (main or master)
-			checkMain := exec.Command("git", "show-ref", "--verify", "--quiet", "refs/heads/main")
-			if err := checkMain.Run(); err == nil {
+			if err := gitRun("show-ref", "--verify", "--quiet", "refs/heads/main"); err == nil {
 				baseBranch = "main"
 			} else {
-				checkMaster := exec.Command("git", "show-ref", "--verify", "--quiet", "refs/heads/master")
-				if err := checkMaster.Run(); err == nil {
+				if err := gitRun("show-ref", "--verify", "--quiet", "refs/heads/master"); err == nil {
 					baseBranch = "master"
 				} else {
 					baseBranch = "main" // Default fallback
@@ -1504,17 +3128,85 @@ func runManagerMode(configFile string, iterations int) error {
 			}
 		}
 
-		prURL, err := createPullRequest(branchName, baseBranch, issue.Identifier, issue.Title, issue.URL, issue.Description)
-		if err != nil {
-			// PR creation failed - escalate but don't fail the workflow
-			errorComment := fmt.Sprintf("⚠️  Work completed but failed to create pull request:\n\n**Error:** %v\n**Branch:** `%s`", err, branchName)
-			usernames := []string{config.EscalateUser}
-			if err := client.addTicketComment(issue.ID, errorComment, usernames); err != nil {
-				fmt.Printf("⚠️  Warning: failed to add error comment: %v\n", err)
+		prURL := managerState.PRURL
+		if managerState.Phase != ManagerPhasePRCreated {
+			// Optionally make sure the branch still merges cleanly before opening a pull request
+			if config.CheckConflicts {
+				conflicts, err := resolveBaseConflicts(baseBranch, branchName, config.AutoMergeBase)
+				if err != nil {
+					statusf("⚠️  Warning: merge conflict check skipped: %v\n", err)
+				} else if len(conflicts) > 0 {
+					statusf("⚠️  Branch %s conflicts with %s in %d file(s), escalating instead of opening a pull request\n", branchName, baseBranch, len(conflicts))
+
+					// Push so the work is available for manual resolution
+					pushNote := ""
+					if err := pushBranchToRemote(branchName); err != nil {
+						statusf("⚠️  Warning: %v\n", err)
+						pushNote = "\n\n(The branch could not be pushed; it is only available locally.)"
+					}
+
+					var fileLines []string
+					for _, file := range conflicts {
+						fileLines = append(fileLines, fmt.Sprintf("- %s", markdownPath(file)))
+					}
+					errorComment := fmt.Sprintf("⚠️  Work completed but the branch conflicts with `%s`, so no pull request was opened.\n\n**Branch:** `%s`\n\n**Conflicting files:**\n%s\n\nPlease resolve the conflicts and open the pull request manually.%s", baseBranch, branchName, strings.Join(fileLines, "\n"), pushNote)
+					usernames := []string{config.EscalateUser}
+					if err := client.addTicketComment(issue.ID, errorComment, usernames); err != nil {
+						statusf("⚠️  Warning: failed to add error comment: %v\n", err)
+					}
+					for _, ticket := range batch {
+						if err := client.addTicketComment(ticket.ID, fmt.Sprintf("⚠️  Batched with %s: the branch `%s` conflicts with `%s`, see %s.", ticketDisplayName(issue), branchName, baseBranch, ticketDisplayName(issue)), nil); err != nil {
+							statusf("⚠️  Warning: failed to add error comment: %v\n", err)
+						}
+					}
+
+					// Leave the ticket In Progress for a human and move on to the next ticket
+					failedTickets = append(failedTickets, fmt.Sprintf("%s: conflicts with %s", ticketDisplayName(issue), baseBranch))
+					metrics.Failed++
+					metrics.CurrentTicket = ""
+					metrics.write()
+					clearManagerState()
+					managerState = nil
+					if once {
+						return fmt.Errorf("ticket %s conflicts with %s, no pull request opened", ticketDisplayName(issue), baseBranch)
+					}
+					continue
+				}
+			}
+
+			var err error
+			prURL, err = createPullRequest(prCreator, branchName, baseBranch, issue.Identifier, issue.Title, issue.URL, issue.Description, batch)
+			if err != nil {
+				// PR creation failed - escalate but don't fail the workflow
+				errorComment := fmt.Sprintf("⚠️  Work completed but failed to create pull request:\n\n**Error:** %v\n**Branch:** `%s`", err, branchName)
+				usernames := []string{config.EscalateUser}
+				if err := client.addTicketComment(issue.ID, errorComment, usernames); err != nil {
+					statusf("⚠️  Warning: failed to add error comment: %v\n", err)
+				}
+				statusf("⚠️  Warning: Failed to create pull request: %v\n", err)
+			} else if prURL != "" {
+				statusf("✅ Pull request created: %s\n", prURL)
+			} else {
+				statusf("✅ Pull request created (URL not available)\n")
+			}
+			if err == nil {
+				managerState.Phase = ManagerPhasePRCreated
+				managerState.PRURL = prURL
+				if err := saveManagerState(managerState); err != nil {
+					statusf("⚠️  Warning: failed to save manager state: %v\n", err)
+				}
 			}
-			fmt.Printf("⚠️  Warning: Failed to create pull request: %v\n", err)
 		} else {
-			fmt.Printf("✅ Pull request created: %s\n", prURL)
+			statusf("⏭️  Pull request already created for ticket %s: %s\n", ticketDisplayName(issue), prURL)
+		}
+
+		// Attach the run artifacts once per pull request, including one reused from an earlier attempt
+		// or opened before a restart
+		if prURL != "" && !managerState.ArtifactsPosted && postPRArtifacts(prCreator, prURL, config) {
+			managerState.ArtifactsPosted = true
+			if err := saveManagerState(managerState); err != nil {
+				statusf("⚠️  Warning: failed to save manager state: %v\n", err)
+			}
 		}
 
 		// Update ticket to "Done"
@@ -1523,19 +3215,50 @@ func runManagerMode(configFile string, iterations int) error {
 		if prURL != "" {
 			successCommentParts = append(successCommentParts, fmt.Sprintf("\n**Pull Request:** %s", prURL))
 		}
+		if config.PostDiffstat {
+			diffstat, err := getDiffstatSummary(baseBranch, branchName)
+			if err != nil {
+				statusf("⚠️  Warning: %v\n", err)
+			} else if diffstat != "" {
+				successCommentParts = append(successCommentParts, "\n"+diffstat)
+			}
+		}
 		successComment := strings.Join(successCommentParts, "\n")
 		if err := client.addTicketComment(issue.ID, successComment, nil); err != nil {
-			fmt.Printf("⚠️  Warning: failed to add success comment: %v\n", err)
+			statusf("⚠️  Warning: failed to add success comment: %v\n", err)
 		}
 
 		if err := client.updateTicketStatus(issue.ID, issue.Team.ID, "Done"); err != nil {
 			return fmt.Errorf("failed to update ticket to Done: %v", err)
 		}
 
-		fmt.Printf("✅ Ticket %s completed successfully!\n", issue.Title)
+		// Batched tickets were done in the same pull request and move to Done with it
+		for _, ticket := range batch {
+			if err := client.addTicketComment(ticket.ID, successComment, nil); err != nil {
+				statusf("⚠️  Warning: failed to add success comment: %v\n", err)
+			}
+			if err := client.updateTicketStatus(ticket.ID, ticket.Team.ID, "Done"); err != nil {
+				return fmt.Errorf("failed to update batched ticket %s to Done: %v", ticketDisplayName(ticket), err)
+			}
+		}
+
+		statusf("✅ Ticket %s completed successfully!\n", issue.Title)
+		succeededTickets = append(succeededTickets, ticketDisplayName(issue))
+		for _, ticket := range batch {
+			succeededTickets = append(succeededTickets, ticketDisplayName(ticket))
+		}
+		consecutiveFailures = 0
+		metrics.Succeeded += 1 + len(batch)
+		metrics.CurrentTicket = ""
+		metrics.write()
 
 		// Clear manager state and continue to next ticket
 		clearManagerState()
 		managerState = nil
+		showQueue = true
+		if once {
+			statusf("ℹ️  One ticket processed, exiting (--once)\n")
+			return nil
+		}
 	}
 }
@@ -0,0 +1,34 @@
+package main
+
+import "testing"
+
+// labeledProvider is an IssueProvider whose tickets all carry the given labels
+type labeledProvider struct {
+	IssueProvider
+	labels []string
+}
+
+func (p *labeledProvider) getIssue(issueID string) (*Issue, error) {
+	issue := &Issue{ID: issueID}
+	for _, name := range p.labels {
+		issue.Labels.Nodes = append(issue.Labels.Nodes, struct {
+			ID   string
+			Name string
+		}{Name: name})
+	}
+	return issue, nil
+}
+
+func TestRestoreMissingBranchUsesTheTicketBase(t *testing.T) {
+	initGitRepo(t)
+	client := &labeledProvider{labels: []string{"bug", "base:release-2.0"}}
+
+	if got := restoreMissingBranch(client, "ENG-1", "ralph/eng-1", "main"); got != "release-2.0" {
+		t.Errorf("deleted branch recreated from %q, want the ticket's base release-2.0", got)
+	}
+
+	runGit(t, "branch", "ralph/eng-1")
+	if got := restoreMissingBranch(client, "ENG-1", "ralph/eng-1", "main"); got != "main" {
+		t.Errorf("existing branch: got base %q, want the configured main", got)
+	}
+}
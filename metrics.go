@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Agent call counters for the metrics file, updated by runClaude
+var (
+	agentCalls        int
+	agentCallFailures int
+	agentCallSeconds  float64
+)
+
+// recordAgentCall adds one agent call and its duration to the metrics counters
+func recordAgentCall(duration time.Duration, failed bool) {
+	agentCalls++
+	agentCallSeconds += duration.Seconds()
+	if failed {
+		agentCallFailures++
+	}
+}
+
+// ManagerMetrics are the manager-session counters written to --metrics-file
+type ManagerMetrics struct {
+	File          string // Prometheus textfile path; empty disables metrics
+	Started       time.Time
+	Succeeded     int
+	Failed        int
+	Iterations    int
+	CurrentTicket string
+}
+
+// write renders the metrics in the Prometheus text format and replaces the file atomically (as the
+// node_exporter textfile collector expects). Failures only warn: metrics must not stop the manager.
+func (m *ManagerMetrics) write() {
+	if m == nil || m.File == "" {
+		return
+	}
+
+	var b strings.Builder
+	metric := func(name, kind, help string, samples ...string) {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+		for _, sample := range samples {
+			fmt.Fprintf(&b, "%s%s\n", name, sample)
+		}
+	}
+	metric("ralph_manager_start_time_seconds", "gauge", "Unix time the manager session started.",
+		fmt.Sprintf(" %d", m.Started.Unix()))
+	metric("ralph_manager_tickets_total", "counter", "Tickets processed this session, by outcome.",
+		fmt.Sprintf(`{outcome="succeeded"} %d`, m.Succeeded), fmt.Sprintf(`{outcome="failed"} %d`, m.Failed))
+	metric("ralph_manager_iterations_total", "counter", "Ralph loop iterations run this session.",
+		fmt.Sprintf(" %d", m.Iterations))
+	metric("ralph_manager_working", "gauge", "1 while a ticket is being worked on.",
+		fmt.Sprintf(" %d", boolMetric(m.CurrentTicket != "")))
+	if m.CurrentTicket != "" {
+		metric("ralph_manager_current_ticket", "gauge", "The ticket being worked on (always 1).",
+			fmt.Sprintf(`{ticket="%s"} 1`, escapeMetricLabel(m.CurrentTicket)))
+	}
+	metric("ralph_agent_calls_total", "counter", "Agent (Claude CLI or agent_command) runs, including retries.",
+		fmt.Sprintf(" %d", agentCalls))
+	metric("ralph_agent_call_failures_total", "counter", "Agent runs that failed or timed out.",
+		fmt.Sprintf(" %d", agentCallFailures))
+	metric("ralph_agent_call_duration_seconds_total", "counter", "Total time spent in agent runs.",
+		fmt.Sprintf(" %.3f", agentCallSeconds))
+	metric("ralph_metrics_updated_time_seconds", "gauge", "Unix time these metrics were written.",
+		fmt.Sprintf(" %d", clock.Now().Unix()))
+
+	tmp := m.File + ".tmp"
+	if err := os.MkdirAll(filepath.Dir(m.File), 0755); err != nil {
+		statusf("⚠️  Warning: failed to write metrics file: %v\n", err)
+		return
+	}
+	if err := os.WriteFile(tmp, []byte(b.String()), 0644); err != nil {
+		statusf("⚠️  Warning: failed to write metrics file: %v\n", err)
+		return
+	}
+	if err := os.Rename(tmp, m.File); err != nil {
+		statusf("⚠️  Warning: failed to write metrics file: %v\n", err)
+	}
+}
+
+// boolMetric returns 1 for true and 0 for false
+func boolMetric(value bool) int {
+	if value {
+		return 1
+	}
+	return 0
+}
+
+// escapeMetricLabel escapes a Prometheus label value
+func escapeMetricLabel(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ANSI styles for status lines
+const (
+	styleReset  = "\033[0m"
+	styleBold   = "\033[1m"
+	styleRed    = "\033[31m"
+	styleGreen  = "\033[32m"
+	styleYellow = "\033[33m"
+)
+
+// colorStdout and colorStderr enable styled status lines on each stream (set by initColor)
+var (
+	colorStdout bool
+	colorStderr bool
+)
+
+// initColor turns styling on for streams attached to a terminal, unless --no-color, NO_COLOR
+// (https://no-color.org) or TERM=dumb turn it off. Redirected output and logs stay plain.
+func initColor() {
+	if cliOptions.NoColor || os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
+		return
+	}
+	colorStdout = isTerminal(os.Stdout)
+	colorStderr = isTerminal(os.Stderr)
+}
+
+// isTerminal reports whether file is a character device (a terminal rather than a pipe or file)
+func isTerminal(file *os.File) bool {
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// statusStyles maps the start of a status line to its style; lines matching none are printed unstyled
+var statusStyles = []struct {
+	prefix string
+	style  string
+}{
+	{"❌", styleRed},
+	{"🚫", styleRed},
+	{"Error:", styleRed},
+	{"⚠️", styleYellow},
+	{"✅", styleGreen},
+	{"🎉", styleGreen},
+}
+
+// statusf prints a status line to stdout, colored by its leading emoji (errors red, warnings yellow, success green)
+func statusf(format string, args ...any) {
+	fmt.Print(styleLine(colorStdout, "", fmt.Sprintf(format, args...)))
+}
+
+// headerf prints a step or iteration header to stdout in bold
+func headerf(format string, args ...any) {
+	fmt.Print(styleLine(colorStdout, styleBold, fmt.Sprintf(format, args...)))
+}
+
+// errorf prints a status line to stderr, styled like statusf
+func errorf(format string, args ...any) {
+	fmt.Fprint(os.Stderr, styleLine(colorStderr, "", fmt.Sprintf(format, args...)))
+}
+
+// styleLine wraps line in style (or, when style is empty, the style of its prefix). Leading and trailing
+// newlines stay outside the escape codes so a colored line never bleeds into the next one.
+func styleLine(enabled bool, style, line string) string {
+	if !enabled {
+		return line
+	}
+	text := strings.Trim(line, "\n")
+	if text == "" {
+		return line
+	}
+	if style == "" {
+		for _, s := range statusStyles {
+			if strings.HasPrefix(text, s.prefix) {
+				style = s.style
+				break
+			}
+		}
+		if style == "" {
+			return line
+		}
+	}
+	start := strings.Index(line, text)
+	return line[:start] + style + text + styleReset + line[start+len(text):]
+}
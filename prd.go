@@ -124,8 +124,9 @@ AUTONOMOUS MODE: You are operating in fully autonomous mode. DO NOT ask question
 Your goal is to simplify the provided PRD so that:
 1. Every task has complexity **easy** or **medium** only (reclassify or split any "hard" tasks).
 2. Each task should be doable in **15-20 minutes**. If a task would take longer, split it into multiple smaller tasks.
-3. Keep verification criteria, logical ordering, and dependencies intact.
-4. Preserve the exact PRD format: same headers, task structure with "**Description:**", "**Verification Criteria:**", "**Complexity:**", "---" separators between tasks.
+3. Merge tasks that are redundant, duplicated, or so granular that they are not worth a separate iteration.
+4. Keep verification criteria, logical ordering, and dependencies intact.
+5. Preserve the exact PRD format: same headers, task structure with "**Description:**", "**Verification Criteria:**", "**Complexity:**", "---" separators between tasks.
 
 CRITICAL OUTPUT REQUIREMENTS:
 - Output ONLY the PRD markdown content - nothing else
@@ -143,17 +144,115 @@ const PRDSimplificationUserPromptTemplate = `Simplify the following PRD accordin
 const PRDSimplificationPreserveCompletedInstruction = `
 CRITICAL - PRESERVE COMPLETED ITEMS: This PRD has already been partially completed. Do NOT modify any task or verification criterion that is already marked complete (checkbox with "- [x]"). Keep their wording and structure exactly as written. Only simplify, split, or reclassify INCOMPLETE tasks (those with "- [ ]"). Preserve every checkbox state ([x] vs [ ]) in your output.`
 
-// createPRD orchestrates the PRD creation process
-func createPRD(description string) error {
-	// Check if PRD already exists
-	if _, err := os.Stat(SamplePRDFile); err == nil {
-		fmt.Printf("⚠️  Warning: %s already exists\n", SamplePRDFile)
-		fmt.Println("The existing PRD will be overwritten with the new one.")
-		fmt.Println()
+// PRDQuestionRetries is how many times PRD creation feeds Claude's own questions back for it to answer autonomously
+const PRDQuestionRetries = 2
+
+// PRDSelfAnswerPromptTemplate is the retry prompt used when Claude asked questions instead of creating a PRD
+// (%s = project description, %s = Claude's previous output)
+const PRDSelfAnswerPromptTemplate = `You were asked to create a PRD for this project, but you responded with questions instead of a PRD.
+
+Project description: %s
+
+Your previous response:
+---
+%s
+---
+
+There is no one to answer these questions. Answer each of them yourself, choosing the most reasonable option for this project and stating it as an assumption, then produce the complete PRD in the required format. Do NOT ask any further questions.`
+
+// PRDTaskRetries is how many times PRD creation asks Claude to redo a PRD that has no parseable tasks
+const PRDTaskRetries = 1
+
+// PRDMissingTasksPromptTemplate is the retry prompt used when the extracted PRD has no "- [ ]" tasks
+const PRDMissingTasksPromptTemplate = `You were asked to create a PRD for this project, but the PRD you produced has no tasks in the required format, so the development loop cannot use it.
+
+Project description: %s
+
+Your previous PRD:
+---
+%s
+---
+
+Rewrite the complete PRD in the required format. Every task must be a markdown checkbox line like "- [ ] **Task 1: Title**", followed by its description and its verification criteria as nested "- [ ]" checkboxes. Do NOT describe the tasks as prose or tables.`
+
+// DefaultMaxDescriptionChars is the default max_description_chars: inline --init descriptions longer than this
+// risk crowding the PRD creation prompt, so Ralph asks for a file instead
+const DefaultMaxDescriptionChars = 20000
+
+// resolvePRDDescription prepares the --init description for the PRD creation prompt. A path to an existing file
+// becomes an @ reference so Claude reads the spec itself; an inline description over max_description_chars is rejected.
+func resolvePRDDescription(description string) (string, error) {
+	path := strings.TrimSpace(description)
+	if info, err := os.Stat(path); err == nil && !info.IsDir() {
+		statusf("📄 Using the project description in %s\n", path)
+		return fmt.Sprintf("the project described in @%s", path), nil
 	}
 
-	fmt.Println("🚀 Starting PRD creation...")
-	fmt.Printf("📝 Project description: %s\n", description)
+	if len(description) > ralphConfig.MaxDescriptionChars {
+		return "", fmt.Errorf("project description is %d characters, over the %d-character limit (max_description_chars in %s). Save it to a file and pass the path instead: ralph --init spec.md",
+			len(description), ralphConfig.MaxDescriptionChars, RalphConfigFile)
+	}
+	return description, nil
+}
+
+// descriptionFromFile reads an --init --from-file description. A file too long to inline
+// (over max_description_chars) is passed on as its path, which resolvePRDDescription turns into an @ reference.
+func descriptionFromFile(path string) (string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read description file: %v", err)
+	}
+	description := strings.TrimSpace(string(content))
+	if description == "" {
+		return "", fmt.Errorf("description file %s is empty", path)
+	}
+	if len(description) > ralphConfig.MaxDescriptionChars {
+		return path, nil
+	}
+	statusf("📄 Read the project description from %s\n", path)
+	return description, nil
+}
+
+// PRDBackupFile holds the previous PRD when --init regenerates it
+const PRDBackupFile = SamplePRDFile + ".bak"
+
+// confirmPRDOverwrite asks before --init replaces an existing PRD and backs it up to PRDBackupFile.
+// yes (--yes) or a non-interactive stdin skips the question. Returns false if the user declined.
+func confirmPRDOverwrite(yes bool) (bool, error) {
+	content, err := os.ReadFile(SamplePRDFile)
+	if os.IsNotExist(err) {
+		return true, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to read %s: %v", SamplePRDFile, err)
+	}
+
+	tasks := parsePRDTasks(string(content))
+	done := 0
+	for _, task := range topLevelTasks(tasks) {
+		if task.Done {
+			done++
+		}
+	}
+	statusf("⚠️  Warning: %s already exists (%d lines, %d top-level task(s), %d done) and will be replaced by a newly generated PRD\n",
+		SamplePRDFile, len(strings.Split(strings.TrimRight(string(content), "\n"), "\n")), len(topLevelTasks(tasks)), done)
+	if !yes && stdinIsTerminal() && !askYesNo("Overwrite it?") {
+		statusf("ℹ️  Aborted; the existing PRD was left unchanged\n")
+		return false, nil
+	}
+
+	if err := writeFileContent(PRDBackupFile, string(content)); err != nil {
+		return false, fmt.Errorf("failed to back up %s: %v", SamplePRDFile, err)
+	}
+	statusf("💾 Backed up the existing PRD to %s\n", PRDBackupFile)
+	fmt.Println()
+	return true, nil
+}
+
+// createPRD orchestrates the PRD creation process
+func createPRD(description string) error {
+	statusf("🚀 Starting PRD creation...\n")
+	statusf("📝 Project description: %s\n", description)
 	fmt.Println()
 	fmt.Println("Claude is analyzing your project and generating a comprehensive PRD...")
 	fmt.Println()
@@ -164,12 +263,16 @@ func createPRD(description string) error {
 		return fmt.Errorf("PRD discovery flow failed: %v", err)
 	}
 
-	// Simplification pass: easy/medium tasks, 15-20 min each
+	// Simplification passes: easy/medium tasks, 15-20 min each
 	fmt.Println("Simplifying PRD (easy/medium tasks, 15-20 min each)...")
-	if simplified, err := prdSimplificationFlow(prdContent, false); err == nil && simplified != "" {
+	simplified, err := simplifyPRDContent(prdContent, false)
+	if err != nil {
+		statusf("⚠️  Simplification skipped: %v\n", err)
+	}
+	if simplified != "" && len(topLevelTasks(parsePRDTasks(simplified))) == 0 {
+		statusf("⚠️  Simplified PRD has no parseable tasks, keeping the unsimplified PRD\n")
+	} else if simplified != "" {
 		prdContent = simplified
-	} else if err != nil {
-		fmt.Printf("⚠️  Simplification skipped: %v\n", err)
 	}
 
 	// Write the PRD to file
@@ -178,7 +281,7 @@ func createPRD(description string) error {
 	}
 
 	fmt.Println()
-	fmt.Println("✅ PRD created successfully!")
+	statusf("✅ PRD created successfully!\n")
 	fmt.Printf("   - %s\n", SamplePRDFile)
 	fmt.Println()
 	fmt.Println("Next steps:")
@@ -194,37 +297,60 @@ func prdDiscoveryFlow(description string) (string, error) {
 	systemPrompt := PRDCreationSystemPrompt
 	userPrompt := fmt.Sprintf(PRDCreationUserPromptTemplate, description)
 
-	// Run Claude with the discovery prompt
-	result, err := runClaude(TimeoutPRDCreation, systemPrompt, userPrompt)
-	if err != nil {
-		// Error is already formatted by formatClaudeError(), just wrap it
-		return "", fmt.Errorf("PRD creation failed: %w", err)
-	}
+	taskRetries := 0
+	for attempt := 0; ; attempt++ {
+		// Run Claude with the discovery prompt
+		result, err := runClaude(ralphConfig.PRDTimeout, systemPrompt, userPrompt)
+		if err != nil {
+			// Error is already formatted by formatClaudeError(), just wrap it
+			return "", fmt.Errorf("PRD creation failed: %w", err)
+		}
 
-	if !result.Success {
-		return "", fmt.Errorf("PRD creation failed: %s", result.Output)
-	}
+		if !result.Success {
+			return "", fmt.Errorf("PRD creation failed: %s", result.Output)
+		}
+
+		// Extract PRD content from the output
+		prdContent := extractPRDFromOutput(result.Output)
+		if prdContent != "" {
+			// A PRD with the right headers but no checkbox tasks (e.g. prose) would leave the loop nothing to do
+			if len(topLevelTasks(parsePRDTasks(prdContent))) > 0 {
+				return prdContent, nil
+			}
+			if taskRetries >= PRDTaskRetries {
+				return "", fmt.Errorf("the generated PRD has no tasks in the \"- [ ] **Task N: ...**\" format (after %d retry). Try again, or write the tasks into %s yourself", PRDTaskRetries, SamplePRDFile)
+			}
+			taskRetries++
+			statusf("⚠️  The generated PRD has no parseable tasks; asking Claude to rewrite it in the task format (retry %d/%d)...\n", taskRetries, PRDTaskRetries)
+			userPrompt = fmt.Sprintf(PRDMissingTasksPromptTemplate, description, prdContent)
+			continue
+		}
 
-	// Extract PRD content from the output
-	prdContent := extractPRDFromOutput(result.Output)
-	if prdContent == "" {
 		// Emit debug info so the user can see what Claude returned and why extraction failed
 		prdEmitExtractionDebug(result.Output)
 
-		// Check if Claude asked questions instead of creating a PRD
-		outputLower := strings.ToLower(result.Output)
-		if strings.Contains(outputLower, "could you please") ||
-			strings.Contains(outputLower, "please provide") ||
-			strings.Contains(outputLower, "what kind of") ||
-			strings.Contains(outputLower, "need more") ||
-			strings.Contains(outputLower, "more details") ||
-			strings.Contains(outputLower, "more information") {
-			return "", fmt.Errorf("PRD creation failed: Claude asked questions instead of creating a PRD. Please ensure the description is more detailed, or the PRD creation prompt enforces autonomous mode.")
+		if !prdAskedQuestions(result.Output) {
+			return "", fmt.Errorf("failed to extract PRD from Claude output. Output length: %d characters", len(result.Output))
 		}
-		return "", fmt.Errorf("failed to extract PRD from Claude output. Output length: %d characters", len(result.Output))
+
+		// Claude asked questions instead of creating a PRD: have it answer them itself and produce the PRD
+		if attempt >= PRDQuestionRetries {
+			return "", fmt.Errorf("PRD creation failed: Claude asked questions instead of creating a PRD (after %d self-answering retries). Please ensure the description is more detailed, or the PRD creation prompt enforces autonomous mode.", PRDQuestionRetries)
+		}
+		statusf("⚠️  Claude asked questions instead of creating a PRD; asking it to answer them itself (retry %d/%d)...\n", attempt+1, PRDQuestionRetries)
+		userPrompt = fmt.Sprintf(PRDSelfAnswerPromptTemplate, description, result.Output)
 	}
+}
 
-	return prdContent, nil
+// prdAskedQuestions reports whether Claude's output looks like clarifying questions rather than a PRD
+func prdAskedQuestions(output string) bool {
+	outputLower := strings.ToLower(output)
+	return strings.Contains(outputLower, "could you please") ||
+		strings.Contains(outputLower, "please provide") ||
+		strings.Contains(outputLower, "what kind of") ||
+		strings.Contains(outputLower, "need more") ||
+		strings.Contains(outputLower, "more details") ||
+		strings.Contains(outputLower, "more information")
 }
 
 // prdSimplificationFlow runs a simplification pass on PRD content (easy/medium only, 15-20 min per task).
@@ -236,7 +362,7 @@ func prdSimplificationFlow(prdContent string, preserveCompleted bool) (string, e
 	}
 	userPrompt := fmt.Sprintf(PRDSimplificationUserPromptTemplate, preserveInstruction, prdContent)
 
-	result, err := runClaude(TimeoutPRDSimplification, PRDSimplificationSystemPrompt, userPrompt)
+	result, err := runClaude(TimeoutPRDSimplification, getPRDSimplificationSystemPrompt(), userPrompt)
 	if err != nil {
 		return "", fmt.Errorf("PRD simplification failed: %w", err)
 	}
@@ -251,6 +377,41 @@ func prdSimplificationFlow(prdContent string, preserveCompleted bool) (string, e
 	return simplified, nil
 }
 
+// simplifyPRDContent runs the configured number of simplification passes over PRD content.
+// It always returns the last accepted content; a pass that drops completion marks or every incomplete task is rejected with an error.
+func simplifyPRDContent(prdContent string, preserveCompleted bool) (string, error) {
+	passes := ralphConfig.SimplifyPasses
+	for pass := 1; pass <= passes; pass++ {
+		if passes > 1 {
+			fmt.Printf("Simplification pass %d/%d...\n", pass, passes)
+		}
+
+		simplified, err := prdSimplificationFlow(prdContent, preserveCompleted)
+		if err != nil {
+			return prdContent, err
+		}
+		if err := checkSimplificationPreservesState(prdContent, simplified); err != nil {
+			return prdContent, fmt.Errorf("simplification pass %d rejected: %v", pass, err)
+		}
+		prdContent = simplified
+	}
+	return prdContent, nil
+}
+
+// checkSimplificationPreservesState guards against a simplification pass that loses task state:
+// completed marks (- [x]) must not disappear, and incomplete work must not vanish entirely.
+func checkSimplificationPreservesState(before, after string) error {
+	completedBefore := strings.Count(before, "- [x]")
+	completedAfter := strings.Count(after, "- [x]")
+	if completedAfter < completedBefore {
+		return fmt.Errorf("simplified PRD has %d completed item(s), expected at least %d", completedAfter, completedBefore)
+	}
+	if strings.Contains(before, "- [ ]") && !strings.Contains(after, "- [ ]") {
+		return fmt.Errorf("simplified PRD has no incomplete tasks left")
+	}
+	return nil
+}
+
 // reprocessPRD reads the existing .ralph/PRD.md, runs simplification (preserving completed items), and writes back.
 func reprocessPRD() error {
 	prdContent, err := readFileContent(SamplePRDFile)
@@ -262,9 +423,13 @@ func reprocessPRD() error {
 	}
 
 	fmt.Println("Simplifying PRD (easy/medium tasks, 15-20 min each; completed tasks left unchanged)...")
-	simplified, err := prdSimplificationFlow(prdContent, true)
+	simplified, err := simplifyPRDContent(prdContent, true)
 	if err != nil {
-		return err
+		if simplified == prdContent {
+			return err
+		}
+		// Keep the output of the passes that succeeded
+		statusf("⚠️  Stopped after a failed simplification pass: %v\n", err)
 	}
 	if simplified == "" {
 		return fmt.Errorf("simplification produced empty output")
@@ -275,7 +440,7 @@ func reprocessPRD() error {
 	}
 
 	fmt.Println()
-	fmt.Println("✅ PRD simplified. Completed tasks were left unchanged.")
+	statusf("✅ PRD simplified. Completed tasks were left unchanged.\n")
 	fmt.Printf("   - %s\n", SamplePRDFile)
 	return nil
 }
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 )
 
 // Built-in prompts - these are used as fallbacks if not found in .ralph directory
@@ -45,7 +46,7 @@ const BuiltInPlanningPrompt = `@.ralph/PRD.md @.ralph/PROGRESS.md @GUARDRAILS.md
    c. Update .ralph/PRD.md by replacing the original task with the subtasks (maintain the same checkbox format) \
    d. Select ONE of the newly created subtasks to work on \
 4. Create a detailed plan for the selected task. Make sure to include vitests, detailed task breakdown and acceptance criteria. \
-5. If @GUARDRAILS.md exists, ensure your plan complies with it (do not propose steps that violate those rules). \
+5. If guardrail files are referenced above, ensure your plan complies with them (do not propose steps that violate those rules). \
 6. Write the plan to .ralph/PLAN.md. \
 ONLY WORK ON ONE TASK. \
 DO NOT ask which task to work on - select one autonomously using the decision-making framework. \
@@ -77,12 +78,20 @@ const BuiltInCleanupPrompt = `@.ralph/PRD.md @.ralph/PLAN.md @.ralph/PROGRESS.md
 5. Update @README.md with any applicable changes. Update README.md only if: 1) New features were added that users should know about, 2) Setup/installation steps changed, 3) Configuration options were added/removed. \
 If no README updates are needed, skip that step - do not ask.`
 
+// BuiltInAgentsRefactorPrompt is the CLAUDE.md refactor step. It resolves contradictions by a fixed precedence
+// because every step runs unattended; asking which version to keep is only appropriate for an interactive
+// (--interactive) mode, which Ralph does not have, so no built-in prompt may wait for an answer.
 const BuiltInAgentsRefactorPrompt = `@CLAUDE.md \
 I want you to refactor my CLAUDE.md file to follow progressive disclosure principles.
 
 Follow these steps:
 
-1. **Find contradictions**: Identify any instructions that conflict with each other. For each contradiction, ask me which version I want to keep.
+1. **Resolve contradictions**: Identify any instructions that conflict with each other. Do NOT ask which version to keep; this step runs unattended. Resolve each contradiction with this precedence:
+   - A project-specific instruction wins over a generic one
+   - A more specific instruction (narrower scope, concrete command or path) wins over a broader one
+   - An instruction consistent with the current codebase and config files wins over one that contradicts them
+   - If still tied, keep the instruction that appears later in the file
+   Record each resolution (kept version, dropped version, rule applied) in a "Resolved contradictions" section of docs/CLAUDE_REFACTOR_NOTES.md so a human can review it.
 
 2. **Identify the essentials**: Extract only what belongs in the root CLAUDE.md:
    - One-sentence project description
@@ -149,21 +158,42 @@ Review git status, stage all relevant changes, and commit - do not ask for appro
 If there are no changes to commit, output 'No changes to commit' and proceed to next iteration.`
 
 const BuiltInGuardrailVerifyPrompt = `@GUARDRAILS.md @.ralph/PRD.md @.ralph/PLAN.md @.ralph/PROGRESS.md @CLAUDE.md \
-1. Read @GUARDRAILS.md and understand all guardrail rules (they verify PRD tasks, plans, and outcome compliance—not code style). \
+1. Read the guardrail files referenced above and understand all guardrail rules (they verify PRD tasks, plans, and outcome compliance—not code style). \
 2. Verify that the completed work and the way the PRD task and plan specified it comply with the guardrails. \
-3. If any guardrail rule is violated (e.g. hardcoded secret, missing verification criterion, prod mocks): apply fixes and list what was fixed. Do not perform a general code-style or lint review. \
+3. If any guardrail rule is violated (e.g. hardcoded secret, missing verification criterion, prod mocks): apply fixes and list what was fixed under a "Fixed:" heading, one "- " item per fix. Do not perform a general code-style or lint review. \
 4. If fully compliant with all guardrails, output <promise>COMPLIANT</promise>. \
 Do not ask for confirmation. Proceed immediately. \
-If you are blocked, output <promise>BLOCKED</promise> and explain.`
+If you are blocked, output <promise>BLOCKED</promise> and list the violations under a "Violations:" heading, one "- " item each.`
+
+// BuiltInFinalGuardrailPromptTemplate is the final guardrail sweep (guardrail_mode final/both); %s is the commit the run started from
+const BuiltInFinalGuardrailPromptTemplate = `@GUARDRAILS.md @.ralph/PRD.md @.ralph/PROGRESS.md @CLAUDE.md \
+1. Read the guardrail files referenced above and understand all guardrail rules (they verify PRD tasks, plans, and outcome compliance—not code style). \
+2. Review every change made in this run: run git diff %s...HEAD and check the completed work as a whole against the guardrails. \
+3. If any guardrail rule is violated: apply fixes, commit them with a message like 'fix: guardrail compliance', and list what was fixed under a "Fixed:" heading, one "- " item per fix. Do not perform a general code-style or lint review. \
+4. If fully compliant with all guardrails, output <promise>COMPLIANT</promise>. \
+Do not ask for confirmation. Proceed immediately. \
+If you are blocked, output <promise>BLOCKED</promise> and list the violations under a "Violations:" heading, one "- " item each.`
 
 const BuiltInPlanGuardrailVerifyPrompt = `@GUARDRAILS.md @.ralph/PLAN.md @.ralph/PRD.md @.ralph/PROGRESS.md \
-1. Read @GUARDRAILS.md and understand all guardrail rules (they verify PRD tasks and plans, not code style). \
+1. Read the guardrail files referenced above and understand all guardrail rules (they verify PRD tasks and plans, not code style). \
 2. Review the plan in .ralph/PLAN.md (not the implementation). Determine if any planned steps would violate any guardrail. \
-3. If violations exist: revise .ralph/PLAN.md to comply, then list what was fixed. \
+3. If violations exist: revise .ralph/PLAN.md to comply, then list what was fixed under a "Fixed:" heading, one "- " item per fix. \
 4. If compliant (or after fixing), output <promise>COMPLIANT</promise>. \
-5. If the plan cannot be made compliant without changing the PRD task, output <promise>BLOCKED</promise> and explain. \
+5. If the plan cannot be made compliant without changing the PRD task, output <promise>BLOCKED</promise> and list the violations under a "Violations:" heading, one "- " item each. \
 Do not ask for confirmation. Proceed immediately.`
 
+// BuiltInProgressSummaryPrompt is the system prompt for condensing PROGRESS.md (progress_max_bytes)
+const BuiltInProgressSummaryPrompt = `You maintain the learnings file of the Ralph Wiggum autonomous development loop.
+
+AUTONOMOUS MODE: You are operating in fully autonomous mode. DO NOT ask questions. Output only the condensed file.
+
+The file has grown too large. Condense it so that:
+1. Every distinct learning survives: gotchas, conventions, commands that work, decisions and their reasons, known issues.
+2. Duplicates, superseded notes and per-iteration narration are merged or dropped.
+3. The result is markdown, grouped by topic, and at most half the length of the original.
+
+Output ONLY the condensed markdown, with no explanatory text before or after it.`
+
 const BuiltInSamplePRD = `# Product Requirements Document
 
 ## Overview
@@ -264,6 +294,8 @@ const (
 	AgentsRefactorPromptFile     = ".ralph/agents_refactor_prompt.txt"
 	SelfImprovementPromptFile    = ".ralph/self_improvement_prompt.txt"
 	CommitPromptFile             = ".ralph/commit_prompt.txt"
+	PRDSimplificationPromptFile  = ".ralph/prd_simplification_prompt.txt"
+	ProgressSummaryPromptFile    = ".ralph/progress_summary_prompt.txt"
 	SamplePRDFile                = ".ralph/PRD.md"
 )
 
@@ -277,6 +309,21 @@ func getSystemPrompt() (string, error) {
 	return BuiltInSystemPrompt, nil
 }
 
+// getStepSystemPrompt returns the system prompt for a step (a StepNames key): .ralph/system_prompt_<step>.txt
+// if present, otherwise the global system prompt
+func getStepSystemPrompt(step string) (string, error) {
+	content, err := readFileContent(stepSystemPromptFile(step))
+	if err == nil {
+		return content, nil
+	}
+	return getSystemPrompt()
+}
+
+// stepSystemPromptFile returns the per-step system prompt override path for a step
+func stepSystemPromptFile(step string) string {
+	return fmt.Sprintf(".ralph/system_prompt_%s.txt", step)
+}
+
 // getStepPrompt returns the prompt for a given step, checking .ralph directory first, then falling back to built-in
 func getStepPrompt(stepNum int) string {
 	var filename string
@@ -305,30 +352,133 @@ func getStepPrompt(stepNum int) string {
 		return builtInPrompt
 	}
 
+	prompt := builtInPrompt
 	content, err := readFileContent(filename)
 	if err == nil {
-		return content
+		prompt = content
 	}
-	// Fall back to built-in prompt
-	return builtInPrompt
+
+	// Recent commit messages give planning continuity with the previous iterations (recent_commits);
+	// the plan must comply with every active guardrail file
+	if stepNum == 1 {
+		prompt = withRecentCommits(withGuardrailRefs(prompt))
+	}
+
+	// Keep self-directed review work away from ignored paths (ignore_paths and .gitignore)
+	if stepNum == 4 || stepNum == 5 {
+		prompt += ignorePathsInstruction()
+	}
+
+	// Reference split PRD files (.ralph/prd/*.md) alongside .ralph/PRD.md
+	if refs := prdDirRefs(); len(refs) > 0 {
+		prompt = strings.Join(refs, " ") + "\n\nThe PRD is split across " + SamplePRDFile + " (if present) and the files in " + PRDDir + "/. Treat them together as one PRD, and mark each task complete in the file where it appears.\n\n" + prompt
+	}
+
+	// Prepend configured context files (context_files in .ralph/config.toml)
+	if refs := contextFileRefs(); len(refs) > 0 {
+		prompt = strings.Join(refs, " ") + "\n\n" + prompt
+	}
+	return prompt
+}
+
+// RecentCommitsPlaceholder is replaced in the planning prompt by the last recent_commits commit messages
+const RecentCommitsPlaceholder = "{{recent_commits}}"
+
+// withRecentCommits fills {{recent_commits}} in the planning prompt. When recent_commits is set and the prompt
+// has no placeholder, the messages are appended; when it is not set, the placeholder is removed.
+func withRecentCommits(prompt string) string {
+	messages := ""
+	if ralphConfig.RecentCommits > 0 {
+		messages = getRecentCommitMessages(ralphConfig.RecentCommits)
+	}
+	if strings.Contains(prompt, RecentCommitsPlaceholder) {
+		return strings.ReplaceAll(prompt, RecentCommitsPlaceholder, messages)
+	}
+	if messages == "" {
+		return prompt
+	}
+	return prompt + "\n\nRecent commits (newest first), for context on what the previous iterations did:\n" + messages
+}
+
+// ignorePathsInstruction returns the exclusion list appended to the refactor and self-improvement prompts
+func ignorePathsInstruction() string {
+	instruction := "\n\nEXCLUDED PATHS: Do not review, refactor, or add tasks about files ignored by .gitignore (generated output, dependencies, build artifacts)."
+	if len(ralphConfig.IgnorePaths) > 0 {
+		instruction += " Also exclude these paths (globs): " + strings.Join(ralphConfig.IgnorePaths, ", ") + "."
+	}
+	return instruction
+}
+
+// prdDirRefs returns @path references for the PRD files in .ralph/prd/
+func prdDirRefs() []string {
+	var refs []string
+	for _, file := range prdDirFiles() {
+		refs = append(refs, "@"+file)
+	}
+	return refs
+}
+
+// GuardrailsRef is the guardrails reference in prompts; withGuardrailRefs points it at the active guardrail files
+const GuardrailsRef = "@" + GuardrailsFile
+
+// withGuardrailRefs replaces @GUARDRAILS.md in the prompt's leading reference line with references to every
+// active guardrail file (guardrails_file, .ralph/guardrails/ and guardrail_scopes). Mentions in the prompt's
+// prose are left alone, so a sentence about the file never turns into a list of files. Without any guardrail
+// files, the prompt is left unchanged.
+func withGuardrailRefs(prompt string) string {
+	files := guardrailFiles()
+	if len(files) == 0 || (len(files) == 1 && files[0] == GuardrailsFile) {
+		return prompt
+	}
+	refs := make([]string, len(files))
+	for i, file := range files {
+		refs[i] = "@" + file
+	}
+	firstLine, rest, multiline := strings.Cut(prompt, "\n")
+	if !strings.Contains(firstLine, GuardrailsRef) {
+		return prompt
+	}
+	firstLine = strings.Replace(firstLine, GuardrailsRef, strings.Join(refs, " "), 1)
+	if !multiline {
+		return firstLine
+	}
+	return firstLine + "\n" + rest
 }
 
 // getGuardrailVerifyPrompt returns the guardrail verification prompt, checking .ralph directory first, then falling back to built-in.
 func getGuardrailVerifyPrompt() string {
 	content, err := readFileContent(GuardrailVerifyPromptFile)
 	if err == nil {
-		return content
+		return withGuardrailRefs(content)
 	}
-	return BuiltInGuardrailVerifyPrompt
+	return withGuardrailRefs(BuiltInGuardrailVerifyPrompt)
 }
 
 // getPlanGuardrailVerifyPrompt returns the plan guardrail verification prompt, checking .ralph directory first, then falling back to built-in.
 func getPlanGuardrailVerifyPrompt() string {
 	content, err := readFileContent(PlanGuardrailVerifyPromptFile)
+	if err == nil {
+		return withGuardrailRefs(content)
+	}
+	return withGuardrailRefs(BuiltInPlanGuardrailVerifyPrompt)
+}
+
+// getPRDSimplificationSystemPrompt returns the PRD simplification system prompt, checking .ralph directory first, then falling back to built-in.
+func getPRDSimplificationSystemPrompt() string {
+	content, err := readFileContent(PRDSimplificationPromptFile)
+	if err == nil {
+		return content
+	}
+	return PRDSimplificationSystemPrompt
+}
+
+// getProgressSummarySystemPrompt returns the PROGRESS.md summarization prompt, checking .ralph directory first, then falling back to built-in.
+func getProgressSummarySystemPrompt() string {
+	content, err := readFileContent(ProgressSummaryPromptFile)
 	if err == nil {
 		return content
 	}
-	return BuiltInPlanGuardrailVerifyPrompt
+	return BuiltInProgressSummaryPrompt
 }
 
 // exportPrompts writes all built-in prompts to the .ralph directory
@@ -353,6 +503,8 @@ func exportPrompts() error {
 		AgentsRefactorPromptFile:     BuiltInAgentsRefactorPrompt,
 		SelfImprovementPromptFile:    BuiltInSelfImprovementPrompt,
 		CommitPromptFile:             BuiltInCommitPrompt,
+		PRDSimplificationPromptFile:  PRDSimplificationSystemPrompt,
+		ProgressSummaryPromptFile:    BuiltInProgressSummaryPrompt,
 	}
 
 	for filename, prompt := range stepPrompts {
@@ -370,7 +522,7 @@ func exportPrompts() error {
 		prdStatus = "(sample)"
 	}
 
-	fmt.Println("✅ Exported all prompts to .ralph directory:")
+	statusf("✅ Exported all prompts to .ralph directory:\n")
 	fmt.Printf("   - %s\n", SystemPromptFile)
 	for filename := range stepPrompts {
 		fmt.Printf("   - %s\n", filename)
@@ -396,19 +548,30 @@ func writeFileContent(filename string, content string) error {
 }
 
 // initProject creates the minimum files needed to get started
-// If description is provided, it will interactively create a PRD using Claude
-func initProject(description string) error {
+// If description is provided, it will interactively create a PRD using Claude; yes skips the overwrite confirmation
+func initProject(description string, yes bool) error {
 	// Ensure .ralph directory exists
 	if err := os.MkdirAll(".ralph", 0755); err != nil {
 		return fmt.Errorf("failed to create .ralph directory: %v", err)
 	}
 
+	// Confirm before regenerating an existing PRD (and before clearing anything)
+	if description != "" {
+		var err error
+		if description, err = resolvePRDDescription(description); err != nil {
+			return err
+		}
+		if ok, err := confirmPRDOverwrite(yes); !ok {
+			return err
+		}
+	}
+
 	// Clear out old files from previous runs
 	oldFiles := []string{".ralph/PROGRESS.md", ".ralph/PLAN.md", "PROGRESS.md", "PLAN.md"}
 	for _, file := range oldFiles {
 		if err := os.Remove(file); err != nil && !os.IsNotExist(err) {
 			// Log but don't fail if file can't be removed (e.g., permissions issue)
-			fmt.Printf("⚠️  Warning: could not remove %s: %v\n", file, err)
+			statusf("⚠️  Warning: could not remove %s: %v\n", file, err)
 		}
 	}
 
@@ -427,7 +590,7 @@ func initProject(description string) error {
 		}
 	}
 
-	fmt.Println("✅ Initialized Ralph project:")
+	statusf("✅ Initialized Ralph project:\n")
 	fmt.Printf("   - %s %s\n", SamplePRDFile, prdStatus)
 	fmt.Println("\nNext steps:")
 	if prdStatus == "(created)" {
@@ -0,0 +1,251 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// PRCreator opens pull requests on the git hosting provider behind the origin remote
+type PRCreator interface {
+	// Name returns a human-readable provider name for log messages
+	Name() string
+	// Validate checks that the tooling and credentials needed to open pull requests are available
+	Validate() error
+	// CreatePullRequest opens a pull request from head into base and returns its URL.
+	// If a pull request already exists for head, it returns the existing URL.
+	// An empty URL with a nil error means the pull request was created but its URL is unknown.
+	CreatePullRequest(title, body, base, head string) (string, error)
+	// FindPullRequest returns the URL of an open pull request from head, or "" if there is none
+	FindPullRequest(head string) (string, error)
+	// CommentPullRequest adds a comment to the pull request at prURL
+	CommentPullRequest(prURL, body string) error
+}
+
+// GitHubPRCreator creates pull requests using the GitHub CLI (gh)
+type GitHubPRCreator struct{}
+
+// Name returns the provider name
+func (g *GitHubPRCreator) Name() string {
+	return "GitHub"
+}
+
+// Validate checks that the GitHub CLI is installed and authenticated
+func (g *GitHubPRCreator) Validate() error {
+	// Check if GitHub CLI is installed
+	ghCmd := exec.Command("gh", "--version")
+	if err := ghCmd.Run(); err != nil {
+		return fmt.Errorf("GitHub CLI (gh) is not installed. Please install it from https://cli.github.com/")
+	}
+
+	// Check if GitHub CLI is authenticated
+	authCmd := exec.Command("gh", "auth", "status")
+	authOutput, err := authCmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("GitHub CLI is not authenticated. Please run: gh auth login\nOutput: %s", string(authOutput))
+	}
+
+	// Verify authentication is valid (check for "Logged in" in output)
+	if !strings.Contains(string(authOutput), "Logged in") {
+		return fmt.Errorf("GitHub CLI authentication appears invalid. Please run: gh auth login")
+	}
+
+	return nil
+}
+
+// CreatePullRequest creates a pull request using GitHub CLI
+func (g *GitHubPRCreator) CreatePullRequest(title, body, base, head string) (string, error) {
+	cmd := exec.Command("gh", "pr", "create",
+		"--title", title,
+		"--body", body,
+		"--base", base,
+		"--head", head,
+	)
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		outputStr := string(output)
+		// Check if PR already exists
+		if strings.Contains(outputStr, "already exists") || strings.Contains(outputStr, "pull request already exists") {
+			// Try to get the existing PR URL
+			getPRCmd := exec.Command("gh", "pr", "view", head, "--json", "url", "--jq", ".url")
+			prOutput, prErr := getPRCmd.Output()
+			if prErr == nil {
+				prURL := strings.TrimSpace(string(prOutput))
+				if prURL != "" {
+					statusf("ℹ️  Pull request already exists: %s\n", prURL)
+					return prURL, nil
+				}
+			}
+			return "", fmt.Errorf("pull request already exists for branch %s", head)
+		}
+		return "", fmt.Errorf("failed to create pull request: %v\nOutput: %s", err, outputStr)
+	}
+
+	// GitHub CLI typically outputs the PR URL as the last line
+	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+	if last := strings.TrimSpace(lines[len(lines)-1]); strings.HasPrefix(last, "http") {
+		return last, nil
+	}
+
+	// If URL not in output, look it up (retry briefly: the PR may not be visible immediately)
+	for attempt := 1; attempt <= 3; attempt++ {
+		getPRCmd := exec.Command("gh", "pr", "view", head, "--json", "url", "--jq", ".url")
+		prOutput, err := getPRCmd.Output()
+		if err == nil {
+			prURL := strings.TrimSpace(string(prOutput))
+			if prURL != "" {
+				return prURL, nil
+			}
+		}
+		if attempt < 3 {
+			clock.Sleep(2 * time.Second)
+		}
+	}
+
+	// PR was created but its URL could not be determined; callers treat "" as "no URL"
+	statusf("⚠️  Warning: pull request created but its URL could not be retrieved\n")
+	return "", nil
+}
+
+// FindPullRequest looks up an open pull request from head using GitHub CLI
+func (g *GitHubPRCreator) FindPullRequest(head string) (string, error) {
+	cmd := exec.Command("gh", "pr", "list", "--head", head, "--state", "open", "--json", "url", "--jq", ".[0].url // \"\"")
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to look up pull requests for %s: %v", head, err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// CommentPullRequest adds a comment to a pull request using GitHub CLI (the body is passed on stdin)
+func (g *GitHubPRCreator) CommentPullRequest(prURL, body string) error {
+	cmd := exec.Command("gh", "pr", "comment", prURL, "--body-file", "-")
+	cmd.Stdin = strings.NewReader(body)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to comment on pull request: %v\nOutput: %s", err, string(output))
+	}
+	return nil
+}
+
+// Run artifacts that can be posted on the pull request (pr_artifacts)
+const (
+	PRArtifactProgress   = "progress"    // Final .ralph/PROGRESS.md learnings
+	PRArtifactTestReport = "test_report" // Output of test_command (or done_command) on the finished branch
+)
+
+// DefaultPRArtifactsMaxBytes bounds the artifacts comment when pr_artifacts_max_bytes is not set
+const DefaultPRArtifactsMaxBytes = 20000
+
+// truncateArtifact shortens content to at most limit bytes, keeping the head or (for logs) the tail
+func truncateArtifact(content string, limit int, keepTail bool) string {
+	if len(content) <= limit {
+		return content
+	}
+	if keepTail {
+		return "... (truncated)\n" + strings.ToValidUTF8(content[len(content)-limit:], "")
+	}
+	return strings.ToValidUTF8(content[:limit], "") + "\n... (truncated)"
+}
+
+// prArtifactsComment builds the pr_artifacts comment: each artifact in a collapsed section, together within
+// pr_artifacts_max_bytes. Returns "" when there is nothing to post.
+func prArtifactsComment(config *LinearConfig) string {
+	maxBytes := config.PRArtifactsMaxBytes
+	if maxBytes == 0 {
+		maxBytes = DefaultPRArtifactsMaxBytes
+	}
+	limit := maxBytes / len(config.PRArtifacts)
+
+	var sections []string
+	for _, artifact := range config.PRArtifacts {
+		switch artifact {
+		case PRArtifactProgress:
+			content, err := readFileContent(ProgressFile)
+			if err != nil || strings.TrimSpace(content) == "" {
+				statusf("⚠️  Warning: %s is missing or empty, not attaching it to the pull request\n", ProgressFile)
+				continue
+			}
+			sections = append(sections, fmt.Sprintf("<details>\n<summary>Learnings (%s)</summary>\n\n%s\n</details>", ProgressFile, truncateArtifact(strings.TrimSpace(content), limit, false)))
+		case PRArtifactTestReport:
+			command := ralphConfig.TestCommand
+			if command == "" {
+				command = ralphConfig.DoneCommand
+			}
+			if command == "" {
+				statusf("⚠️  Warning: no test_command or done_command in %s, not attaching a test report\n", RalphConfigFile)
+				continue
+			}
+			statusf("🧪 Running %s for the pull request test report\n", command)
+			output, err := runTestCommand(command)
+			status := "passed"
+			if err != nil {
+				status = fmt.Sprintf("failed: %v", err)
+			}
+			report := truncateArtifact(strings.TrimRight(output, "\n"), limit, true)
+			fence := codeFence(report)
+			sections = append(sections, fmt.Sprintf("<details>\n<summary>Test report: <code>%s</code> (%s)</summary>\n\n%s\n%s\n%s\n</details>", html.EscapeString(command), html.EscapeString(status), fence, report, fence))
+		}
+	}
+	if len(sections) == 0 {
+		return ""
+	}
+	return "### Ralph run artifacts\n\n" + strings.Join(sections, "\n\n")
+}
+
+// postPRArtifacts comments the configured run artifacts (pr_artifacts) on the pull request and reports whether
+// it did. Failures only warn.
+func postPRArtifacts(creator PRCreator, prURL string, config *LinearConfig) bool {
+	if len(config.PRArtifacts) == 0 || prURL == "" {
+		return false
+	}
+	body := prArtifactsComment(config)
+	if body == "" {
+		return false
+	}
+	if err := creator.CommentPullRequest(prURL, body); err != nil {
+		statusf("⚠️  Warning: failed to attach run artifacts to the pull request: %v\n", err)
+		return false
+	}
+	statusf("📎 Attached run artifacts (%s) to the pull request\n", strings.Join(config.PRArtifacts, ", "))
+	return true
+}
+
+// codeFence returns a backtick fence longer than any backtick run in content (at least three),
+// so the content can't close the code block early
+func codeFence(content string) string {
+	longest, run := 0, 0
+	for _, r := range content {
+		if r == '`' {
+			run++
+			if run > longest {
+				longest = run
+			}
+		} else {
+			run = 0
+		}
+	}
+	if longest < 3 {
+		return "```"
+	}
+	return strings.Repeat("`", longest+1)
+}
+
+// getOriginRemoteURL returns the URL of the origin remote
+func getOriginRemoteURL() (string, error) {
+	output, err := gitOutput("remote", "get-url", "origin")
+	if err != nil {
+		return "", fmt.Errorf("failed to get origin remote URL: %v", err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// maskSecret hides all but the last four characters of a credential for logging
+func maskSecret(secret string) string {
+	if len(secret) <= 4 {
+		return strings.Repeat("*", len(secret))
+	}
+	return strings.Repeat("*", 8) + secret[len(secret)-4:]
+}
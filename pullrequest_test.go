@@ -0,0 +1,39 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPRArtifactsTestReportEscaping(t *testing.T) {
+	inTempDir(t)
+	config := defaultRalphConfig()
+	config.TestCommand = "printf '```\\n<b>done</b>\\n' && test 1 -lt 2"
+	withRalphConfig(t, config)
+
+	comment := prArtifactsComment(&LinearConfig{PRArtifacts: []string{PRArtifactTestReport}})
+
+	if !strings.Contains(comment, "<code>printf &#39;```\\n&lt;b&gt;done&lt;/b&gt;\\n&#39; &amp;&amp; test 1 -lt 2</code>") {
+		t.Errorf("test_command not HTML-escaped in the summary:\n%s", comment)
+	}
+	if !strings.Contains(comment, "````\n```\n<b>done</b>\n````\n") {
+		t.Errorf("output containing ``` not wrapped in a longer fence:\n%s", comment)
+	}
+}
+
+func TestCodeFence(t *testing.T) {
+	tests := []struct {
+		content string
+		want    string
+	}{
+		{"plain output", "```"},
+		{"inline `code` and ``pairs``", "```"},
+		{"```\nfenced\n```", "````"},
+		{"a ````` run", "``````"},
+	}
+	for _, tt := range tests {
+		if got := codeFence(tt.content); got != tt.want {
+			t.Errorf("codeFence(%q) = %q, want %q", tt.content, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ReviewReportFile is where --review-only writes its findings
+const ReviewReportFile = ".ralph/REVIEW.md"
+
+// ReviewOnlyInstruction is appended to the analysis prompts in --review-only mode
+const ReviewOnlyInstruction = `
+
+REVIEW-ONLY MODE: This overrides any instruction above to edit files. Do NOT modify, create, or delete any files (including .ralph/PRD.md), and do NOT run git commit. Instead, output your findings as markdown in your response: for each finding give its severity, location (file and code section), evidence, and suggested fix. If there are no findings, say so.`
+
+// runReview runs the self-improvement and guardrail analyses against the current tree without changing code,
+// and writes the findings to .ralph/REVIEW.md
+func runReview() error {
+	for _, filename := range requiredFiles(ModeReview) {
+		if !requiredFileExists(filename) {
+			return &configError{fmt.Errorf("required file %s not found", filename)}
+		}
+	}
+
+	statusBefore := strings.Join(getUncommittedFiles(), "\n")
+	branch, _ := getCurrentGitBranch()
+
+	var sections []string
+
+	systemPrompt, err := getStepSystemPrompt("self_improvement")
+	if err != nil {
+		return fmt.Errorf("failed to get system prompt: %v", err)
+	}
+	result, err := executeStepWithRetry(5, "🔍 Review: self-improvement analysis...", TimeoutSelfImprovement, stepRetries("self_improvement"), systemPrompt, getStepPrompt(5)+ReviewOnlyInstruction)
+	if err != nil {
+		return err
+	}
+	sections = append(sections, "## Code Review Findings\n\n"+result.Output)
+
+	if guardrailsExists() {
+		systemPrompt, err := getStepSystemPrompt("guardrail")
+		if err != nil {
+			return fmt.Errorf("failed to get system prompt: %v", err)
+		}
+		result, err := executeStepWithRetry(0, "🛡️ Review: guardrail verification...", TimeoutGuardrail, stepRetries("guardrail"), systemPrompt, getGuardrailVerifyPrompt()+ReviewOnlyInstruction)
+		if err != nil {
+			return err
+		}
+		sections = append(sections, "## Guardrail Findings\n\n"+result.Output)
+	} else {
+		sections = append(sections, fmt.Sprintf("## Guardrail Findings\n\nSkipped: no guardrails found at %s.", guardrailsLocation()))
+	}
+
+	// The analysis must not have touched the tree
+	if statusAfter := strings.Join(getUncommittedFiles(), "\n"); statusAfter != statusBefore {
+		statusf("⚠️  Warning: the working tree changed during the review; inspect it with git status before continuing\n")
+	}
+
+	header := fmt.Sprintf("# Ralph Review\n\n**Branch:** `%s`\n**Date:** %s", branch, clock.Now().Format("2006-01-02 15:04"))
+	report := header + "\n\n" + strings.Join(sections, "\n\n") + "\n"
+	if err := writeFileContent(ReviewReportFile, report); err != nil {
+		return fmt.Errorf("failed to write %s: %v", ReviewReportFile, err)
+	}
+
+	statusf("✅ Review written to %s\n", ReviewReportFile)
+	return nil
+}
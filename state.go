@@ -120,14 +120,14 @@ func detectResumeWithPrompt(maxIterations int, interactive bool) (*State, int, e
 
 	// Validate state
 	if state.Iteration == 0 || state.MaxIterations == 0 {
-		fmt.Fprintf(os.Stderr, "⚠️  State file is corrupted. Starting fresh.\n")
+		errorf("⚠️  State file is corrupted. Starting fresh.\n")
 		clearState()
 		return nil, 0, nil
 	}
 
 	// Check if iteration exceeds max
 	if state.Iteration > state.MaxIterations {
-		fmt.Fprintf(os.Stderr, "⚠️  State file indicates iteration exceeds max. Starting fresh.\n")
+		errorf("⚠️  State file indicates iteration exceeds max. Starting fresh.\n")
 		clearState()
 		return nil, 0, nil
 	}
@@ -164,7 +164,7 @@ func detectResumeWithPrompt(maxIterations int, interactive bool) (*State, int, e
 
 	// Prompt user if interactive mode
 	if interactive {
-		fmt.Println("🔄 Resume detected:")
+		statusf("🔄 Resume detected:\n")
 		fmt.Printf("   Iteration: %d/%d\n", resumeIteration, state.MaxIterations)
 		fmt.Printf("   Resume from: %s\n", stepName)
 		fmt.Println()
@@ -181,8 +181,185 @@ func detectResumeWithPrompt(maxIterations int, interactive bool) (*State, int, e
 			return nil, 0, nil
 		}
 	} else {
-		fmt.Printf("🔄 Auto-resuming from iteration %d/%d, %s\n", resumeIteration, state.MaxIterations, stepName)
+		statusf("🔄 Auto-resuming from iteration %d/%d, %s\n", resumeIteration, state.MaxIterations, stepName)
 	}
 
 	return state, resumeStep, nil
 }
+
+// applyResumeOverride replaces the detected resume point with a manual one (--resume-iteration / --resume-step).
+// Max iterations still come from the state file. Steps: 1 = Workflow 1, 2 = Workflow 2, 3 = task check after Workflow 2.
+func applyResumeOverride(iteration int, step int) (*State, error) {
+	state, err := loadState()
+	if err != nil {
+		return nil, err
+	}
+	if state == nil || state.MaxIterations == 0 {
+		return nil, fmt.Errorf("no usable state in %s to resume (max iterations unknown)", StateFile)
+	}
+
+	if iteration < 1 || iteration > state.MaxIterations {
+		return nil, fmt.Errorf("resume iteration %d out of range (1-%d)", iteration, state.MaxIterations)
+	}
+	if step < 1 || step > 3 {
+		return nil, fmt.Errorf("resume step %d invalid (1 = Workflow 1, 2 = Workflow 2, 3 = task check)", step)
+	}
+
+	errorf("⚠️  Manual resume override in effect: iteration %d/%d, step %d (state file had iteration %d, last completed workflow %d)\n",
+		iteration, state.MaxIterations, step, state.Iteration, state.LastCompletedWorkflow)
+
+	state.Iteration = iteration
+	state.LastCompletedWorkflow = step - 1
+	state.CurrentStep = step
+	if state.CurrentStep > 2 {
+		state.CurrentStep = 2
+	}
+	if err := saveState(state); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+// stateKeyMigrations maps legacy state keys to their canonical names
+var stateKeyMigrations = map[string]string{
+	"last_completed_step": "last_completed_workflow",
+}
+
+// migrateState rewrites the state file in the canonical key=value format, reporting how each key was mapped.
+// With dryRun, the mapping is shown but nothing is written.
+func migrateState(dryRun bool) error {
+	content, err := os.ReadFile(StateFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			statusf("ℹ️  No state file at %s, nothing to migrate\n", StateFile)
+			return nil
+		}
+		return fmt.Errorf("failed to read %s: %v", StateFile, err)
+	}
+
+	canonical := map[string]bool{
+		"iteration":               true,
+		"max_iterations":          true,
+		"current_step":            true,
+		"last_completed_workflow": true,
+	}
+
+	changed := false
+	for _, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		parts := strings.SplitN(line, "=", 2)
+		if len(parts) != 2 {
+			fmt.Printf("   ✗ %q: not key=value, dropped\n", line)
+			changed = true
+			continue
+		}
+
+		key := strings.TrimSpace(parts[0])
+		value := strings.TrimSpace(parts[1])
+		if newKey, ok := stateKeyMigrations[key]; ok {
+			fmt.Printf("   → %s=%s becomes %s=%s\n", key, value, newKey, value)
+			changed = true
+		} else if canonical[key] {
+			fmt.Printf("   = %s=%s\n", key, value)
+		} else {
+			fmt.Printf("   ✗ %s=%s: unknown key, dropped\n", key, value)
+			changed = true
+		}
+	}
+
+	state, err := loadState()
+	if err != nil {
+		return fmt.Errorf("failed to parse %s: %v", StateFile, err)
+	}
+
+	if !changed {
+		statusf("✅ %s is already in the current format\n", StateFile)
+		return nil
+	}
+	if dryRun {
+		statusf("ℹ️  Dry run: %s not modified\n", StateFile)
+		return nil
+	}
+
+	if err := saveState(state); err != nil {
+		return fmt.Errorf("failed to write %s: %v", StateFile, err)
+	}
+	statusf("✅ Migrated %s (iteration %d/%d, last completed workflow %d)\n", StateFile, state.Iteration, state.MaxIterations, state.LastCompletedWorkflow)
+	return nil
+}
+
+// CleanFiles are the run artifacts removed by --clean (PRD and prompt customizations are kept)
+var CleanFiles = []string{StateFile, ManagerStateFile, PlanFile, ProgressFile}
+
+// CleanAllFiles are the extra artifacts removed by --clean --all (archived plans, progress history, iteration log,
+// review report); the raw stream files listed in RawStreamIndexFile are removed too
+var CleanAllFiles = []string{PlanArchiveDir, ProgressHistoryFile, IterationLogFile, ReviewReportFile, RawStreamIndexFile}
+
+// recordedRawStreamFiles returns the --raw-stream-file outputs listed in RawStreamIndexFile
+func recordedRawStreamFiles() []string {
+	content, err := os.ReadFile(RawStreamIndexFile)
+	if err != nil {
+		return nil
+	}
+	var paths []string
+	for _, line := range strings.Split(string(content), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			paths = append(paths, line)
+		}
+	}
+	return paths
+}
+
+// askYesNo prints question with a [y/N] prompt and reports whether the user answered yes
+func askYesNo(question string) bool {
+	fmt.Printf("%s [y/N] ", question)
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+// stdinIsTerminal reports whether stdin is an interactive terminal (false when piped or in CI)
+func stdinIsTerminal() bool {
+	info, err := os.Stdin.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// cleanRalphState removes Ralph's state and run artifacts, asking for confirmation unless yes is set
+func cleanRalphState(all bool, yes bool) error {
+	candidates := CleanFiles
+	if all {
+		candidates = append(append(append([]string{}, CleanFiles...), recordedRawStreamFiles()...), CleanAllFiles...)
+	}
+
+	var targets []string
+	for _, path := range candidates {
+		if _, err := os.Stat(path); err == nil {
+			targets = append(targets, path)
+		}
+	}
+	if len(targets) == 0 {
+		statusf("✅ Nothing to clean\n")
+		return nil
+	}
+
+	fmt.Println("The following will be removed:")
+	for _, path := range targets {
+		fmt.Printf("   - %s\n", path)
+	}
+	if !yes && !askYesNo("Continue?") {
+		statusf("ℹ️  Aborted; nothing removed\n")
+		return nil
+	}
+
+	for _, path := range targets {
+		if err := os.RemoveAll(path); err != nil {
+			return fmt.Errorf("failed to remove %s: %v", path, err)
+		}
+		statusf("🗑️  Removed %s\n", path)
+	}
+	statusf("✅ Removed %d item(s)\n", len(targets))
+	return nil
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"strings"
@@ -31,12 +32,162 @@ func lastOutputSnippet(output string) string {
 	return snippet
 }
 
-func executeStepWithRetry(stepNum int, stepName string, timeout int, systemPrompt string, prompt string) (*ClaudeResult, error) {
-	for attempt := 0; attempt < MaxRetries; attempt++ {
+// checkProgressLearnings warns when the cleanup step dropped earlier PROGRESS.md learnings, and
+// appends newly added lines to .ralph/progress-history.md when progress_history is enabled
+func checkProgressLearnings(iteration int, before string) {
+	after, err := readFileContent(ProgressFile)
+	if err != nil {
+		if before != "" {
+			statusf("⚠️  Warning: %s was removed during cleanup; earlier learnings may be lost\n", ProgressFile)
+		}
+		return
+	}
+
+	beforeLines := nonEmptyLines(before)
+	afterLines := nonEmptyLines(after)
+
+	// Prior content kept verbatim, or at most modestly condensed, is fine
+	if !strings.Contains(after, strings.TrimSpace(before)) && float64(len(after)) < float64(len(before))*(1-ProgressShrinkThreshold) {
+		var lost []string
+		for _, line := range strings.Split(before, "\n") {
+			if trimmed := strings.TrimSpace(line); trimmed != "" && !afterLines[trimmed] {
+				lost = append(lost, trimmed)
+			}
+		}
+		statusf("⚠️  Warning: %s shrank from %d to %d bytes during cleanup; %d earlier line(s) are gone\n", ProgressFile, len(before), len(after), len(lost))
+		for i, line := range lost {
+			if i == 5 {
+				fmt.Printf("   ... and %d more\n", len(lost)-5)
+				break
+			}
+			fmt.Printf("   - %s\n", line)
+		}
+	}
+
+	if !ralphConfig.ProgressHistory {
+		return
+	}
+
+	var added []string
+	for _, line := range strings.Split(after, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed != "" && !beforeLines[trimmed] {
+			added = append(added, line)
+		}
+	}
+	if len(added) == 0 {
+		return
+	}
+
+	file, err := os.OpenFile(ProgressHistoryFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		statusf("⚠️  Warning: failed to open %s: %v\n", ProgressHistoryFile, err)
+		return
+	}
+	defer file.Close()
+
+	entry := fmt.Sprintf("## Iteration %d (%s)\n\n%s\n\n", iteration, clock.Now().Format("2006-01-02 15:04"), strings.Join(added, "\n"))
+	if _, err := file.WriteString(entry); err != nil {
+		statusf("⚠️  Warning: failed to write %s: %v\n", ProgressHistoryFile, err)
+	}
+}
+
+// summarizeProgress condenses PROGRESS.md with a dedicated Claude pass once it exceeds progress_max_bytes,
+// so the learnings referenced by every step stop growing the context. Failures only warn; the file is kept as is.
+func summarizeProgress() {
+	if ralphConfig.ProgressMaxBytes == 0 {
+		return
+	}
+	content, err := readFileContent(ProgressFile)
+	if err != nil || len(content) <= ralphConfig.ProgressMaxBytes {
+		return
+	}
+
+	statusf("\n🗜️  %s is %d bytes (progress_max_bytes = %d), condensing it (timeout: %ds)\n", ProgressFile, len(content), ralphConfig.ProgressMaxBytes, TimeoutProgressSummary)
+	prompt := fmt.Sprintf("Condense the following %s according to the rules you were given. Output only the condensed markdown.\n\n--- %s ---\n\n%s", ProgressFile, ProgressFile, content)
+	result, err := runClaude(TimeoutProgressSummary, getProgressSummarySystemPrompt(), prompt)
+	if err != nil {
+		statusf("⚠️  Warning: %s summarization failed: %v\n", ProgressFile, err)
+		return
+	}
+
+	summary := strings.TrimSpace(result.Output)
+	if !result.Success || summary == "" || len(summary) >= len(content) {
+		statusf("⚠️  Warning: %s summarization produced no shorter version (%d bytes), keeping the original\n", ProgressFile, len(summary))
+		return
+	}
+	if err := writeFileContent(ProgressFile, summary+"\n"); err != nil {
+		statusf("⚠️  Warning: failed to write %s: %v\n", ProgressFile, err)
+		return
+	}
+	statusf("✅ %s condensed from %d to %d bytes\n", ProgressFile, len(content), len(summary)+1)
+}
+
+// nonEmptyLines returns the set of trimmed, non-blank lines in content
+func nonEmptyLines(content string) map[string]bool {
+	lines := make(map[string]bool)
+	for _, line := range strings.Split(content, "\n") {
+		if trimmed := strings.TrimSpace(line); trimmed != "" {
+			lines[trimmed] = true
+		}
+	}
+	return lines
+}
+
+// archivePlan copies .ralph/PLAN.md to .ralph/plans/iter-N-<task-slug>.md and returns the archive path.
+// The slug comes from the plan's first heading (or first line). Returns "" if there is no plan to archive.
+func archivePlan(iteration int) (string, error) {
+	content, err := readFileContent(PlanFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", err
+	}
+
+	slug := ""
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(line), "#"))
+		if line != "" {
+			slug = slugify(line)
+			break
+		}
+	}
+	if len(slug) > 50 {
+		slug = strings.Trim(slug[:50], "-")
+	}
+	if slug == "" {
+		slug = "plan"
+	}
+
+	// Workflow 1 can plan several tasks within one iteration, so avoid overwriting earlier archives
+	base := fmt.Sprintf("%s/iter-%d-%s", PlanArchiveDir, iteration, slug)
+	path := base + ".md"
+	for n := 2; ; n++ {
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			break
+		}
+		path = fmt.Sprintf("%s-%d.md", base, n)
+	}
+
+	if err := writeFileContent(path, content); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+func executeStepWithRetry(stepNum int, stepName string, timeout int, retries int, systemPrompt string, prompt string) (*ClaudeResult, error) {
+	rawStreamStep = stepName
+	defer func() { rawStreamStep = "" }()
+
+	for attempt := 0; attempt < retries; attempt++ {
 		if attempt > 0 {
-			fmt.Printf("\n🔄 Retrying %s (attempt %d/%d)...\n", stepName, attempt+1, MaxRetries)
+			// Back off so a rate limit or overloaded API has time to recover before the next attempt
+			delay := StepRetryDelay << (attempt - 1)
+			statusf("\n🔄 Retrying %s in %s (attempt %d/%d)...\n", stepName, delay, attempt+1, retries)
+			clock.Sleep(delay)
 		} else {
-			fmt.Printf("\n%s (timeout: %ds)\n", stepName, timeout)
+			headerf("\n%s (timeout: %ds)\n", stepName, timeout)
 		}
 
 		result, err := runClaude(timeout, systemPrompt, prompt)
@@ -47,27 +198,38 @@ func executeStepWithRetry(stepNum int, stepName string, timeout int, systemPromp
 		}
 
 		if err != nil {
+			// A fired deadline means the partial output was cut off mid-run, not a clean failure
+			if result != nil && result.Truncated {
+				statusf("✂️  %s output truncated by timeout after %ds (%d chars received)\n", stepName, timeout, len(result.Output))
+			}
+
 			// Check for timeout errors (they may be formatted differently now)
 			errStr := err.Error()
-			if strings.Contains(errStr, "timeout") || strings.Contains(errStr, "Request timeout") {
-				if attempt >= MaxRetries-1 {
-					fmt.Printf("⏱️  %s timed out after %d attempts\n", stepName, MaxRetries)
+			if (result != nil && result.Truncated) || strings.Contains(errStr, "timeout") || strings.Contains(errStr, "Request timeout") {
+				if attempt >= retries-1 {
+					statusf("⏱️  %s timed out after %d attempts\n", stepName, retries)
 					if result != nil && result.Output != "" {
 						snippet := lastOutputSnippet(result.Output)
 						fmt.Printf("Last output before timeout:\n%s\n", snippet)
 					}
-					return result, err
+					return result, &agentError{err}
 				}
-				fmt.Printf("⏱️  %s timed out after %ds, will retry...\n", stepName, timeout)
+				statusf("⏱️  %s timed out after %ds, will retry...\n", stepName, timeout)
 				if result != nil && result.Output != "" {
 					snippet := lastOutputSnippet(result.Output)
 					fmt.Printf("Last output before timeout:\n%s\n", snippet)
 				}
 				continue
 			}
+			// A prompt over prompt_budget_bytes is a files/config problem: don't report it as an agent failure
+			var configErr *configError
+			if errors.As(err, &configErr) {
+				errorf("❌ %s not run: %v\n", stepName, err)
+				return result, err
+			}
 			// Display formatted error message (already includes user-friendly formatting)
-			fmt.Printf("❌ %s failed:\n%s\n", stepName, err.Error())
-			return result, err
+			statusf("❌ %s failed:\n%s\n", stepName, err.Error())
+			return result, &agentError{err}
 		}
 
 		if result.Success {
@@ -75,105 +237,143 @@ func executeStepWithRetry(stepNum int, stepName string, timeout int, systemPromp
 		}
 	}
 
-	return nil, fmt.Errorf("%s failed after %d attempts", stepName, MaxRetries)
+	return nil, &agentError{fmt.Errorf("%s failed after %d attempts", stepName, retries)}
 }
 
 func planning(iteration, maxIterations int) (*ClaudeResult, error) {
-	systemPrompt, err := getSystemPrompt()
+	systemPrompt, err := getStepSystemPrompt("planning")
 	if err != nil {
 		return nil, fmt.Errorf("failed to get system prompt: %v", err)
 	}
 
 	prompt := getStepPrompt(1)
 
-	return executeStepWithRetry(1, "📋 Planning...", TimeoutPlanning, systemPrompt, prompt)
+	return executeStepWithRetry(1, "📋 Planning...", TimeoutPlanning, stepRetries("planning"), systemPrompt, prompt)
 }
 
 func implementation(iteration, maxIterations int) (*ClaudeResult, error) {
-	systemPrompt, err := getSystemPrompt()
+	systemPrompt, err := getStepSystemPrompt("implementation")
 	if err != nil {
 		return nil, fmt.Errorf("failed to get system prompt: %v", err)
 	}
 
 	prompt := getStepPrompt(2)
 
-	return executeStepWithRetry(2, "🔨 Implementation and Validation...", TimeoutImplementation, systemPrompt, prompt)
+	return executeStepWithRetry(2, "🔨 Implementation and Validation...", TimeoutImplementation, stepRetries("implementation"), systemPrompt, prompt)
 }
 
 func cleanup(iteration, maxIterations int) (*ClaudeResult, error) {
-	systemPrompt, err := getSystemPrompt()
+	systemPrompt, err := getStepSystemPrompt("cleanup")
 	if err != nil {
 		return nil, fmt.Errorf("failed to get system prompt: %v", err)
 	}
 
 	prompt := getStepPrompt(3)
 
-	return executeStepWithRetry(3, "🧹 Cleanup and Documentation...", TimeoutCleanup, systemPrompt, prompt)
+	return executeStepWithRetry(3, "🧹 Cleanup and Documentation...", TimeoutCleanup, stepRetries("cleanup"), systemPrompt, prompt)
 }
 
 func agentsRefactor(iteration, maxIterations int) (*ClaudeResult, error) {
-	systemPrompt, err := getSystemPrompt()
+	systemPrompt, err := getStepSystemPrompt("refactor")
 	if err != nil {
 		return nil, fmt.Errorf("failed to get system prompt: %v", err)
 	}
 
 	prompt := getStepPrompt(4)
 
-	return executeStepWithRetry(4, "📝 Agents Refactor (CLAUDE.md)...", TimeoutCleanup, systemPrompt, prompt)
+	return executeStepWithRetry(4, "📝 Agents Refactor (CLAUDE.md)...", TimeoutCleanup, stepRetries("refactor"), systemPrompt, prompt)
 }
 
 func selfImprovement(iteration, maxIterations int) (*ClaudeResult, error) {
-	systemPrompt, err := getSystemPrompt()
+	systemPrompt, err := getStepSystemPrompt("self_improvement")
 	if err != nil {
 		return nil, fmt.Errorf("failed to get system prompt: %v", err)
 	}
 
 	prompt := getStepPrompt(5)
 
-	return executeStepWithRetry(5, fmt.Sprintf("🔍 Self-Improvement (iteration %d)...", iteration), TimeoutSelfImprovement, systemPrompt, prompt)
+	return executeStepWithRetry(5, fmt.Sprintf("🔍 Self-Improvement (iteration %d)...", iteration), TimeoutSelfImprovement, stepRetries("self_improvement"), systemPrompt, prompt)
 }
 
 func commit(iteration, maxIterations int) (*ClaudeResult, error) {
-	systemPrompt, err := getSystemPrompt()
+	systemPrompt, err := getStepSystemPrompt("commit")
 	if err != nil {
 		return nil, fmt.Errorf("failed to get system prompt: %v", err)
 	}
 
 	prompt := getStepPrompt(6)
 
-	return executeStepWithRetry(6, "💾 Commit...", TimeoutCommit, systemPrompt, prompt)
+	return executeStepWithRetry(6, "💾 Commit...", TimeoutCommit, stepRetries("commit"), systemPrompt, prompt)
 }
 
 func planGuardrailVerify(iteration, maxIterations int) (*ClaudeResult, error) {
-	systemPrompt, err := getSystemPrompt()
+	systemPrompt, err := getStepSystemPrompt("plan_guardrail")
 	if err != nil {
 		return nil, fmt.Errorf("failed to get system prompt: %v", err)
 	}
 
 	prompt := getPlanGuardrailVerifyPrompt()
 
-	return executeStepWithRetry(0, "🛡️ Plan guardrail verification...", TimeoutGuardrail, systemPrompt, prompt)
+	return executeStepWithRetry(0, "🛡️ Plan guardrail verification...", TimeoutGuardrail, stepRetries("plan_guardrail"), systemPrompt, prompt)
 }
 
 func guardrailVerify(iteration, maxIterations int) (*ClaudeResult, error) {
-	systemPrompt, err := getSystemPrompt()
+	systemPrompt, err := getStepSystemPrompt("guardrail")
 	if err != nil {
 		return nil, fmt.Errorf("failed to get system prompt: %v", err)
 	}
 
 	prompt := getGuardrailVerifyPrompt()
 
-	return executeStepWithRetry(0, "🛡️ Guardrail verification...", TimeoutGuardrail, systemPrompt, prompt)
+	return executeStepWithRetry(0, "🛡️ Guardrail verification...", TimeoutGuardrail, stepRetries("guardrail"), systemPrompt, prompt)
+}
+
+// finalGuardrailSweep verifies all changes since base against GUARDRAILS.md in one pass (guardrail_mode final/both)
+func finalGuardrailSweep(base string) (*GuardrailReport, error) {
+	systemPrompt, err := getStepSystemPrompt("guardrail")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get system prompt: %v", err)
+	}
+	if base == "" {
+		base = "HEAD"
+	}
+
+	prompt := withGuardrailRefs(fmt.Sprintf(BuiltInFinalGuardrailPromptTemplate, base))
+	result, err := executeStepWithRetry(0, "🛡️ Final guardrail sweep...", TimeoutGuardrail, stepRetries("guardrail"), systemPrompt, prompt)
+	if err != nil {
+		return nil, err
+	}
+	warnMissingGuardrailVerdict("final", result)
+	return newGuardrailReport(result), nil
+}
+
+// warnMissingGuardrailVerdict warns when a guardrail check emitted neither COMPLIANT nor BLOCKED;
+// silence is not treated as verified compliance
+func warnMissingGuardrailVerdict(stage string, result *ClaudeResult) {
+	if result == nil || result.Compliant || result.Blocked {
+		return
+	}
+	statusf("⚠️  Warning: %s guardrail verification emitted no COMPLIANT or BLOCKED marker; compliance is unverified\n", stage)
 }
 
 // workflow1PlanAndImplement runs planning, implementation, and commit in sequence
 // Returns the result from planning step (which contains Complete flag)
 func workflow1PlanAndImplement(iteration, maxIterations int) (*ClaudeResult, error) {
+	commitPerStep := cliOptions.CommitPerStep || ralphConfig.CommitPerStep
+	iterationBase := getHeadCommit()
+
+	// With persist_session, plan → implement → commit continue one Claude session
+	startAgentSession()
+	defer endAgentSession()
+
 	// Planning
 	result, err := planning(iteration, maxIterations)
 	if err != nil {
 		return nil, err
 	}
+	if commitPerStep {
+		wipCommit("plan", iteration)
+	}
 
 	// If blocked or complete, return early
 	if result.Blocked || result.Complete {
@@ -186,12 +386,25 @@ func workflow1PlanAndImplement(iteration, maxIterations int) (*ClaudeResult, err
 		if err != nil {
 			return nil, err
 		}
+		warnMissingGuardrailVerdict("plan", planGuardrailResult)
 		if planGuardrailResult != nil && planGuardrailResult.Blocked {
 			result.Blocked = true
 			return result, nil
 		}
 	}
 
+	// Human plan approval (manager mode with require_plan_approval)
+	if planApprovalGate != nil {
+		approved, err := planApprovalGate(iteration)
+		if err != nil {
+			return nil, err
+		}
+		if !approved {
+			result.Blocked = true
+			return result, nil
+		}
+	}
+
 	// Implementation
 	implResult, err := implementation(iteration, maxIterations)
 	if err != nil {
@@ -201,43 +414,260 @@ func workflow1PlanAndImplement(iteration, maxIterations int) (*ClaudeResult, err
 		result.Blocked = true
 		return result, nil
 	}
+	if commitPerStep {
+		wipCommit("implement", iteration)
+	}
 
-	// Guardrail verification (if GUARDRAILS.md exists)
-	if guardrailsExists() {
-		_, err = guardrailVerify(iteration, maxIterations)
+	// Guardrail verification (if GUARDRAILS.md exists; guardrail_mode final defers it to the end of the run)
+	if guardrailsExists() && guardrailPerIteration() {
+		guardrailResult, err := guardrailVerify(iteration, maxIterations)
+		if err != nil {
+			return nil, err
+		}
+		warnMissingGuardrailVerdict("implementation", guardrailResult)
+		result.Guardrails = newGuardrailReport(guardrailResult)
+	}
+
+	// Independent test gate (test_command in .ralph/config.toml)
+	if ralphConfig.TestCommand != "" {
+		passed, err := testGate(iteration, maxIterations)
 		if err != nil {
 			return nil, err
 		}
+		if !passed {
+			result.Blocked = true
+			return result, nil
+		}
+	}
+
+	// Archive the plan before cleanup removes it (--keep-plans)
+	if cliOptions.KeepPlans || ralphConfig.KeepPlans {
+		if archived, err := archivePlan(iteration); err != nil {
+			statusf("⚠️  Warning: failed to archive plan: %v\n", err)
+		} else if archived != "" {
+			statusf("🗄️  Plan archived to %s\n", archived)
+		}
 	}
 
 	// Cleanup (remove PLAN.md, update PROGRESS/CLAUDE/README)
+	progressBefore, _ := readFileContent(ProgressFile)
 	_, err = cleanup(iteration, maxIterations)
 	if err != nil {
 		return nil, err
 	}
+	checkProgressLearnings(iteration, progressBefore)
 
-	// Commit (update PRD task complete, then stage and commit)
+	// Commit (update PRD task complete, then stage and commit), but never onto a detached HEAD or the wrong branch
+	if err := checkCommitBranch(); err != nil {
+		statusf("❌ %v\n", err)
+		return nil, err
+	}
+	headBefore := getHeadCommit()
 	_, err = commit(iteration, maxIterations)
 	if err != nil {
 		return nil, err
 	}
+	if commitPerStep {
+		squashStepCommits(iterationBase, iteration)
+	}
+	addCommitTrailers(headBefore, iteration)
+
+	// Optionally treat repeated passes without a commit as a stuck loop
+	if ralphConfig.FailOnEmptyCommit {
+		if err := checkEmptyCommit(headBefore); err != nil {
+			return nil, err
+		}
+	}
 
 	return result, nil
 }
 
+// expectedCommitBranch, when set, is the only branch commits may land on (manager mode sets the ticket branch)
+var expectedCommitBranch string
+
+// checkCommitBranch refuses to commit from a detached HEAD, or from a branch other than expectedCommitBranch
+func checkCommitBranch() error {
+	branch, err := getCurrentGitBranch()
+	if err != nil {
+		return err
+	}
+	if branch == "HEAD" {
+		return fmt.Errorf("refusing to commit: HEAD is detached, so the work would not be on any branch. Check out a branch (git switch -c <name> keeps the current work) and rerun")
+	}
+	if expectedCommitBranch != "" && branch != expectedCommitBranch {
+		return fmt.Errorf("refusing to commit: on branch %s but expected %s. Switch back with git switch %s and rerun", branch, expectedCommitBranch, expectedCommitBranch)
+	}
+	return nil
+}
+
+// planApprovalGate, when set, runs between planning and implementation; returning false blocks the pass.
+// Manager mode sets it for require_plan_approval.
+var planApprovalGate func(iteration int) (bool, error)
+
+// scopeLocked, when set, skips the self-improvement step so no tasks are added to the PRD.
+// Manager mode sets it for manager_scope_lock.
+var scopeLocked bool
+
+// emptyCommitStreak counts consecutive plan/implement passes that produced no commit (fail_on_empty_commit)
+var emptyCommitStreak int
+
+// WipCommitPrefix starts the message of step commits made with --commit-per-step
+const WipCommitPrefix = "wip("
+
+// wipCommit commits all changes after a step as "wip(<step>): iteration N" (--commit-per-step).
+// Nothing is committed when the step left no changes.
+func wipCommit(step string, iteration int) {
+	if err := checkCommitBranch(); err != nil {
+		statusf("⚠️  Warning: skipping the %s step commit: %v\n", step, err)
+		return
+	}
+	if err := gitRun("add", "-A"); err != nil {
+		statusf("⚠️  Warning: failed to stage changes for the %s step commit: %v\n", step, err)
+		return
+	}
+	// diff --cached --quiet exits 0 when nothing is staged
+	if gitRun("diff", "--cached", "--quiet") == nil {
+		return
+	}
+	message := fmt.Sprintf("%s%s): iteration %d", WipCommitPrefix, step, iteration)
+	if output, err := gitCombinedOutput("commit", "--no-verify", "-m", message); err != nil {
+		statusf("⚠️  Warning: failed to commit after the %s step: %v: %s\n", step, err, strings.TrimSpace(string(output)))
+		return
+	}
+	statusf("📌 Committed %s\n", message)
+}
+
+// squashStepCommits folds the iteration's wip step commits into the commit step's commit, keeping its message,
+// so branch history (and manager-mode pull requests) stay one commit per iteration. The unsquashed history is
+// kept at refs/ralph/steps/iter-N for bisecting. If the commit step made no commit, the wip commits are left as-is.
+func squashStepCommits(base string, iteration int) {
+	if base == "" {
+		return
+	}
+	output, err := gitOutput("log", "--format=%s", base+"..HEAD")
+	if err != nil {
+		return
+	}
+	trimmed := strings.TrimSpace(string(output))
+	if trimmed == "" {
+		return
+	}
+	subjects := strings.Split(trimmed, "\n")
+	if len(subjects) < 2 {
+		return
+	}
+	// git log lists newest first: the first subject is the commit step's commit
+	if strings.HasPrefix(subjects[0], WipCommitPrefix) {
+		statusf("⚠️  Warning: the commit step made no commit; leaving %d step commit(s) unsquashed\n", len(subjects))
+		return
+	}
+
+	message, err := gitOutput("log", "-1", "--format=%B")
+	if err != nil {
+		return
+	}
+	stepsRef := fmt.Sprintf("refs/ralph/steps/iter-%d", iteration)
+	if err := gitRun("update-ref", stepsRef, "HEAD"); err != nil {
+		statusf("⚠️  Warning: failed to save step commits to %s: %v\n", stepsRef, err)
+		return
+	}
+	if err := gitRun("reset", "--soft", base); err != nil {
+		statusf("⚠️  Warning: failed to squash step commits: %v\n", err)
+		return
+	}
+	if output, err := gitCombinedOutput("commit", "--no-verify", "-m", strings.TrimSpace(string(message))); err != nil {
+		// Restore the unsquashed history rather than leaving the changes uncommitted
+		gitRun("reset", "--soft", stepsRef)
+		statusf("⚠️  Warning: failed to squash step commits, keeping them: %v: %s\n", err, strings.TrimSpace(string(output)))
+		return
+	}
+	statusf("📌 Squashed %d step commit(s) into the iteration commit (unsquashed: %s)\n", len(subjects)-1, stepsRef)
+}
+
+// checkEmptyCommit updates emptyCommitStreak after the commit step and returns a "no progress" error
+// once max_empty_commits consecutive passes left HEAD unchanged with a clean working tree
+func checkEmptyCommit(headBefore string) error {
+	if getHeadCommit() != headBefore || len(getUncommittedFiles()) > 0 {
+		emptyCommitStreak = 0
+		return nil
+	}
+
+	emptyCommitStreak++
+	statusf("⚠️  Warning: no commit this pass and working tree is clean (%d/%d consecutive)\n", emptyCommitStreak, ralphConfig.MaxEmptyCommits)
+	if emptyCommitStreak >= ralphConfig.MaxEmptyCommits {
+		return fmt.Errorf("no progress: %d consecutive passes produced no commit and no changes (HEAD %s). The loop appears stuck; review .ralph/PRD.md and PROGRESS.md", emptyCommitStreak, headBefore)
+	}
+	return nil
+}
+
 // workflow2CleanupAndReview runs refactoring and self-improvement in sequence
 func workflow2CleanupAndReview(iteration, maxIterations int) error {
-	// CLAUDE.md Refactoring
-	_, err := agentsRefactor(iteration, maxIterations)
+	startAgentSession()
+	defer endAgentSession()
+
+	// CLAUDE.md Refactoring (only when CLAUDE.md exists, unless forced)
+	if claudeMDExists() || cliOptions.ForceRefactor || ralphConfig.ForceRefactor {
+		_, err := agentsRefactor(iteration, maxIterations)
+		if err != nil {
+			return err
+		}
+	} else {
+		statusf("\n⏭️  Skipping CLAUDE.md refactor (%s not found; use --force-refactor to create one)\n", ClaudeMDFile)
+	}
+
+	// Self-Improvement (adds PRD tasks, so it is skipped when the scope is locked)
+	if scopeLocked {
+		statusf("\n⏭️  Skipping self-improvement (manager_scope_lock: the ticket's scope is fixed)\n")
+		return nil
+	}
+	_, err := selfImprovement(iteration, maxIterations)
 	if err != nil {
 		return err
 	}
 
-	// Self-Improvement
-	_, err = selfImprovement(iteration, maxIterations)
+	return nil
+}
+
+// OnlyWorkflows are the workflows --only can run on their own; only self-improve needs a PRD
+var OnlyWorkflows = []string{"self-improve", "guardrail", "refactor"}
+
+// runOnlyWorkflow runs a single named workflow ad hoc, outside the development loop
+func runOnlyWorkflow(name string) error {
+	mode := ModeOnly
+	if name == "self-improve" {
+		mode = ModeSelfImprove
+	}
+	for _, filename := range requiredFiles(mode) {
+		if !requiredFileExists(filename) {
+			return &configError{fmt.Errorf("required file %s not found (--only %s adds its findings to the PRD)", filename, name)}
+		}
+	}
+
+	var result *ClaudeResult
+	var err error
+	switch name {
+	case "self-improve":
+		result, err = selfImprovement(1, 1)
+	case "guardrail":
+		if !guardrailsExists() {
+			return fmt.Errorf("no guardrails found at %s; create one with --init-guardrails", guardrailsLocation())
+		}
+		result, err = guardrailVerify(1, 1)
+		if err == nil {
+			warnMissingGuardrailVerdict("guardrail", result)
+			statusf("🛡️  Guardrails: %s\n", newGuardrailReport(result))
+		}
+	case "refactor":
+		result, err = agentsRefactor(1, 1)
+	default:
+		return fmt.Errorf("unknown workflow %q for --only (valid: %s)", name, strings.Join(OnlyWorkflows, ", "))
+	}
 	if err != nil {
 		return err
 	}
-
+	if result.Blocked {
+		return fmt.Errorf("%s workflow %w", name, errBlocked)
+	}
+	statusf("✅ %s workflow finished\n", name)
 	return nil
 }
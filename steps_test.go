@@ -0,0 +1,21 @@
+package main
+
+import "testing"
+
+func TestBlockedOnlyWorkflowExitsBlocked(t *testing.T) {
+	inTempDir(t)
+	config := defaultRalphConfig()
+	config.AgentCommand = `sh -c "echo '<promise>BLOCKED</promise>'"`
+	withRalphConfig(t, config)
+	previous := cliOptions
+	cliOptions.Quiet = true
+	t.Cleanup(func() { cliOptions = previous })
+
+	err := runOnlyWorkflow("refactor")
+	if err == nil {
+		t.Fatal("runOnlyWorkflow succeeded, want a blocked error")
+	}
+	if code := exitCode(false, err); code != ExitBlocked {
+		t.Errorf("exit code %d for %v, want %d (blocked)", code, err, ExitBlocked)
+	}
+}
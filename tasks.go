@@ -0,0 +1,304 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// PRDTask is a checkbox item parsed from .ralph/PRD.md
+type PRDTask struct {
+	Text   string // Text after the checkbox
+	Done   bool   // true for "- [x]"
+	Indent int    // Leading whitespace width (tabs count as 4); top-level tasks have the smallest indent
+	Line   int    // 1-based line number in File
+	File   string // PRD file the task lives in (.ralph/PRD.md or a file in .ralph/prd/)
+}
+
+// prdCheckboxPattern matches markdown checkbox list items ("- [ ] ...", "* [x] ...")
+var prdCheckboxPattern = regexp.MustCompile(`^(\s*)[-*+]\s+\[([ xX])\]\s*(.*)$`)
+
+// parsePRDTasks returns every checkbox item in the PRD content, in document order
+func parsePRDTasks(content string) []PRDTask {
+	var tasks []PRDTask
+	inCodeBlock := false
+	for i, line := range strings.Split(content, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inCodeBlock = !inCodeBlock
+			continue
+		}
+		if inCodeBlock {
+			continue
+		}
+
+		matches := prdCheckboxPattern.FindStringSubmatch(line)
+		if matches == nil {
+			continue
+		}
+		tasks = append(tasks, PRDTask{
+			Text:   strings.TrimSpace(matches[3]),
+			Done:   matches[2] != " ",
+			Indent: len(strings.ReplaceAll(matches[1], "\t", "    ")),
+			Line:   i + 1,
+		})
+	}
+	return tasks
+}
+
+// topLevelTasks returns the tasks at the shallowest indentation of their file (tasks, not their verification criteria)
+func topLevelTasks(tasks []PRDTask) []PRDTask {
+	minIndent := make(map[string]int)
+	for _, task := range tasks {
+		if indent, ok := minIndent[task.File]; !ok || task.Indent < indent {
+			minIndent[task.File] = task.Indent
+		}
+	}
+
+	var result []PRDTask
+	for _, task := range tasks {
+		if task.Indent == minIndent[task.File] {
+			result = append(result, task)
+		}
+	}
+	return result
+}
+
+// PRDTaskCriteria is a top-level task with the checkbox items nested under it (its verification criteria)
+type PRDTaskCriteria struct {
+	Task     PRDTask
+	Criteria []PRDTask
+}
+
+// groupPRDCriteria pairs each top-level task with the nested checkboxes that follow it in the same file
+func groupPRDCriteria(tasks []PRDTask) []PRDTaskCriteria {
+	topLevel := make(map[string]bool)
+	for _, task := range topLevelTasks(tasks) {
+		topLevel[fmt.Sprintf("%s:%d", task.File, task.Line)] = true
+	}
+
+	var groups []PRDTaskCriteria
+	for _, task := range tasks {
+		if topLevel[fmt.Sprintf("%s:%d", task.File, task.Line)] {
+			groups = append(groups, PRDTaskCriteria{Task: task})
+			continue
+		}
+		if len(groups) > 0 && groups[len(groups)-1].Task.File == task.File {
+			groups[len(groups)-1].Criteria = append(groups[len(groups)-1].Criteria, task)
+		}
+	}
+	return groups
+}
+
+// printCriteriaReport lists, for each incomplete top-level task, the verification criteria that are still unchecked.
+// Returns the number of incomplete tasks.
+func printCriteriaReport() (int, error) {
+	tasks, err := loadPRDTasks()
+	if err != nil {
+		return 0, err
+	}
+
+	incomplete := 0
+	for _, group := range groupPRDCriteria(tasks) {
+		if group.Task.Done {
+			continue
+		}
+		incomplete++
+		if incomplete == 1 {
+			statusf("📋 Unmet verification criteria:\n")
+		}
+
+		var unmet []PRDTask
+		for _, criterion := range group.Criteria {
+			if !criterion.Done {
+				unmet = append(unmet, criterion)
+			}
+		}
+		if len(group.Criteria) == 0 {
+			fmt.Printf("   %s (%s:%d): no verification criteria\n", prdTaskTitle(group.Task), group.Task.File, group.Task.Line)
+			continue
+		}
+		fmt.Printf("   %s (%s:%d): %d/%d criteria met\n", prdTaskTitle(group.Task), group.Task.File, group.Task.Line, len(group.Criteria)-len(unmet), len(group.Criteria))
+		for _, criterion := range unmet {
+			fmt.Printf("     - [ ] %s\n", prdTaskTitle(criterion))
+		}
+	}
+	if incomplete == 0 {
+		statusf("✅ Every top-level PRD task is checked off\n")
+	}
+	return incomplete, nil
+}
+
+// prdDirFiles returns the markdown files in .ralph/prd/, sorted by name
+func prdDirFiles() []string {
+	files, err := filepath.Glob(filepath.Join(PRDDir, "*.md"))
+	if err != nil {
+		return nil
+	}
+	sort.Strings(files)
+	return files
+}
+
+// prdFiles returns every PRD file in use: .ralph/PRD.md (if present) followed by the files in .ralph/prd/
+func prdFiles() []string {
+	var files []string
+	if _, err := os.Stat(SamplePRDFile); err == nil {
+		files = append(files, SamplePRDFile)
+	}
+	return append(files, prdDirFiles()...)
+}
+
+// loadPRDTasks parses the checkbox items across all PRD files
+func loadPRDTasks() ([]PRDTask, error) {
+	files := prdFiles()
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no PRD found at %s or in %s", SamplePRDFile, PRDDir)
+	}
+
+	var tasks []PRDTask
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		for _, task := range parsePRDTasks(string(content)) {
+			task.File = file
+			tasks = append(tasks, task)
+		}
+	}
+	return tasks, nil
+}
+
+// prdTopLevelTasksDone reports whether the PRD has tasks and every top-level task is checked off
+func prdTopLevelTasksDone() bool {
+	tasks, err := loadPRDTasks()
+	if err != nil {
+		return false
+	}
+	topLevel := topLevelTasks(tasks)
+	if len(topLevel) == 0 {
+		return false
+	}
+	for _, task := range topLevel {
+		if !task.Done {
+			return false
+		}
+	}
+	return true
+}
+
+// loadPRDTasksMidRun loads the PRD tasks while a run is in progress. A PRD that disappeared, became unreadable
+// or lost all its tasks (e.g. a step deleted or truncated it) is an error, never an empty task list that reads as completion.
+func loadPRDTasksMidRun(when string) ([]PRDTask, error) {
+	tasks, err := loadPRDTasks()
+	if err == nil && len(tasks) == 0 {
+		err = fmt.Errorf("no tasks left in %s", strings.Join(prdFiles(), ", "))
+	}
+	if err != nil {
+		return nil, &configError{fmt.Errorf("the PRD became unusable %s: %v. This is not treated as completion; restore the PRD (e.g. git checkout -- %s) and resume with --continue", when, err, SamplePRDFile)}
+	}
+	return tasks, nil
+}
+
+// addedPRDTasks returns the top-level tasks in after that were not present in before (matched by text)
+func addedPRDTasks(before, after []PRDTask) []PRDTask {
+	seen := make(map[string]int)
+	for _, task := range topLevelTasks(before) {
+		seen[task.Text]++
+	}
+
+	var added []PRDTask
+	for _, task := range topLevelTasks(after) {
+		if seen[task.Text] > 0 {
+			seen[task.Text]--
+			continue
+		}
+		added = append(added, task)
+	}
+	return added
+}
+
+// prdTaskTitle returns a short display title for a task, without markdown emphasis
+func prdTaskTitle(task PRDTask) string {
+	return strings.TrimSpace(strings.ReplaceAll(task.Text, "**", ""))
+}
+
+// PRDTemplateTaskName is the placeholder task title used by the sample PRD
+const PRDTemplateTaskName = "[Task Name]"
+
+// checkPRDActionable returns an error when the PRD has no tasks at all, or only the sample PRD's
+// placeholder tasks, so the loop doesn't run Claude against nothing. Some placeholder tasks only warn.
+func checkPRDActionable() error {
+	tasks, err := loadPRDTasks()
+	if err != nil {
+		return err
+	}
+	if len(tasks) == 0 {
+		return fmt.Errorf("the PRD (%s) has no tasks. Add tasks as markdown checkboxes (- [ ] **Task 1: ...**), or generate a PRD with: ralph --init \"<description>\"", strings.Join(prdFiles(), ", "))
+	}
+
+	var placeholders []PRDTask
+	topLevel := topLevelTasks(tasks)
+	for _, task := range topLevel {
+		if strings.Contains(task.Text, PRDTemplateTaskName) {
+			placeholders = append(placeholders, task)
+		}
+	}
+	if len(placeholders) == len(topLevel) {
+		return fmt.Errorf("the PRD looks like the unedited template: every task is still named %q. Replace the sample tasks with real ones, or generate a PRD with: ralph --init \"<description>\"", PRDTemplateTaskName)
+	}
+	for _, task := range placeholders {
+		statusf("⚠️  Warning: %s:%d: task is still named %q (unedited template task)\n", task.File, task.Line, PRDTemplateTaskName)
+	}
+
+	return checkPRDPlaceholders()
+}
+
+// prdPlaceholderPattern matches the bracketed placeholders of the sample PRD and the PRD creation template
+// ([PROJECT NAME], [Primary objective 1], [Specific, measurable criterion 2], [easy/medium/hard], ...)
+var prdPlaceholderPattern = regexp.MustCompile(`\[(PROJECT NAME|CLEAR DESCRIPTION OF WHAT THIS PRD IS TRYING TO ACCOMPLISH|Brief description of what you're building and why|Primary objective \d+|Task Name|Clear description of what needs to be done|Specific, measurable criterion \d+|easy/medium/hard|Continue with more tasks as needed)\]`)
+
+// PRDPlaceholderReportLimit caps how many placeholder locations are listed in the warning
+const PRDPlaceholderReportLimit = 5
+
+// findPRDPlaceholders returns "file:line: [placeholder]" for each template placeholder left in the PRD files
+func findPRDPlaceholders() []string {
+	var found []string
+	for _, file := range prdFiles() {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		for i, line := range strings.Split(string(content), "\n") {
+			for _, match := range prdPlaceholderPattern.FindAllString(line, -1) {
+				found = append(found, fmt.Sprintf("%s:%d: %s", file, i+1, match))
+			}
+		}
+	}
+	return found
+}
+
+// checkPRDPlaceholders warns when the PRD still contains template placeholders.
+// With --strict-prd (or strict_prd in config) it returns an error instead.
+func checkPRDPlaceholders() error {
+	found := findPRDPlaceholders()
+	if len(found) == 0 {
+		return nil
+	}
+
+	if cliOptions.StrictPRD || ralphConfig.StrictPRD {
+		return fmt.Errorf("the PRD appears to be the unedited template (%d placeholder(s) such as %s); fill them in or rerun without --strict-prd", len(found), found[0])
+	}
+
+	statusf("⚠️  Warning: the PRD appears to contain unedited template placeholders (%d found):\n", len(found))
+	for i, location := range found {
+		if i == PRDPlaceholderReportLimit {
+			fmt.Printf("   ... and %d more\n", len(found)-i)
+			break
+		}
+		fmt.Printf("   - %s\n", location)
+	}
+	return nil
+}
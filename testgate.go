@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// TestOutputMaxChars caps how much failing test output is fed back to Claude (the tail is kept)
+const TestOutputMaxChars = 4000
+
+// runTestCommand runs the configured test_command via sh -c and returns its combined output
+func runTestCommand(command string) (string, error) {
+	ctx, cancel := contextWithTimeout(TimeoutTestCommand)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	output, err := cmd.CombinedOutput()
+	if ctx.Err() == context.DeadlineExceeded {
+		return string(output), fmt.Errorf("test command timed out after %ds", TimeoutTestCommand)
+	}
+	return string(output), err
+}
+
+// DoneTaskTitle is the title of the PRD task added when done_command fails
+const DoneTaskTitle = "Make the definition-of-done command pass"
+
+// doneGate runs done_command when the PRD looks complete. If it fails, a corrective task carrying the
+// command's output is appended to the PRD and false is returned, so the loop goes back to Workflow 1.
+func doneGate() (bool, error) {
+	command := ralphConfig.DoneCommand
+	statusf("\n🏁 Running definition of done: %s\n", command)
+	output, err := runTestCommand(command)
+	if err == nil {
+		statusf("✅ Definition of done passed\n")
+		return true, nil
+	}
+	statusf("❌ Definition of done failed: %v\n", err)
+
+	if len(output) > TestOutputMaxChars {
+		output = "...\n" + output[len(output)-TestOutputMaxChars:]
+	}
+	var indented []string
+	for _, line := range strings.Split(strings.TrimRight(output, "\n"), "\n") {
+		indented = append(indented, "  "+line)
+	}
+	task := fmt.Sprintf(`
+
+- [ ] **%s**
+
+  **Description:** The PRD's tasks are complete, but the definition-of-done command `+"`%s`"+` fails (%v). Fix the cause without weakening, skipping, or deleting tests.
+
+  **Verification Criteria:**
+  - [ ] `+"`%s`"+` exits successfully
+
+  **Output:**
+  `+"```"+`
+%s
+  `+"```"+`
+`, DoneTaskTitle, command, err, command, strings.Join(indented, "\n"))
+
+	files := prdFiles()
+	if len(files) == 0 {
+		return false, fmt.Errorf("no PRD file to add the definition-of-done task to")
+	}
+	content, readErr := readFileContent(files[0])
+	if readErr != nil {
+		return false, fmt.Errorf("failed to read %s: %v", files[0], readErr)
+	}
+	if err := writeFileContent(files[0], strings.TrimRight(content, "\n")+task); err != nil {
+		return false, fmt.Errorf("failed to add definition-of-done task: %v", err)
+	}
+	statusf("📝 Added task %q to %s\n", DoneTaskTitle, files[0])
+	return false, nil
+}
+
+// testGate runs test_command after implementation. On failure, Claude gets the output and a chance to fix it,
+// up to test_fix_attempts times. Returns false if the tests still fail, so the iteration can be marked blocked.
+func testGate(iteration, maxIterations int) (bool, error) {
+	command := ralphConfig.TestCommand
+
+	for attempt := 0; ; attempt++ {
+		statusf("\n🧪 Running test gate: %s\n", command)
+		output, testErr := runTestCommand(command)
+		if testErr == nil {
+			statusf("✅ Test gate passed\n")
+			return true, nil
+		}
+
+		statusf("❌ Test gate failed: %v\n", testErr)
+		if attempt >= ralphConfig.TestFixAttempts {
+			statusf("❌ Tests still failing after %d fix attempt(s)\n", attempt)
+			return false, nil
+		}
+
+		systemPrompt, err := getStepSystemPrompt("implementation")
+		if err != nil {
+			return false, fmt.Errorf("failed to get system prompt: %v", err)
+		}
+		prompt := testFixPrompt(command, testErr, output)
+
+		result, err := executeStepWithRetry(2, fmt.Sprintf("🔧 Fixing test failures (attempt %d/%d)...", attempt+1, ralphConfig.TestFixAttempts), TimeoutImplementation, stepRetries("implementation"), systemPrompt, prompt)
+		if err != nil {
+			return false, err
+		}
+		if result.Blocked {
+			return false, nil
+		}
+	}
+}
+
+// testFixPrompt asks Claude to fix a failing test_command, given its error (exit status or timeout)
+// and the tail of its output
+func testFixPrompt(command string, testErr error, output string) string {
+	if len(output) > TestOutputMaxChars {
+		output = "...\n" + output[len(output)-TestOutputMaxChars:]
+	}
+	return fmt.Sprintf(`@.ralph/PRD.md @.ralph/PLAN.md \
+The project's test command failed after implementation. Fix the code so it passes. \
+Do not weaken, skip, or delete tests to make them pass. \
+If the failure cannot be fixed within the scope of the current task, output <promise>BLOCKED</promise> with the reason.
+
+Command: %s
+Error: %v
+
+Output:
+%s`, command, testErr, output)
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestTestFixPromptIncludesTheFailure(t *testing.T) {
+	command := "echo FAIL: TestParse; exit 3"
+	output, testErr := runTestCommand(command)
+	if testErr == nil {
+		t.Fatal("runTestCommand succeeded, want exit status 3")
+	}
+
+	prompt := testFixPrompt(command, testErr, output)
+	for _, want := range []string{"Command: " + command, "Error: exit status 3", "FAIL: TestParse"} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("fix prompt is missing %q:\n%s", want, prompt)
+		}
+	}
+	if strings.Contains(prompt, "<nil>") {
+		t.Errorf("fix prompt reports a nil error:\n%s", prompt)
+	}
+}
+
+func TestTestFixPromptKeepsTheOutputTail(t *testing.T) {
+	output := strings.Repeat("x", TestOutputMaxChars) + "the last line"
+	prompt := testFixPrompt("make test", errors.New("exit status 1"), output)
+	if !strings.Contains(prompt, "...\n") || !strings.HasSuffix(prompt, "the last line") {
+		t.Errorf("fix prompt did not keep the tail of long output")
+	}
+	if len(prompt) > TestOutputMaxChars+1000 {
+		t.Errorf("fix prompt is %d chars, want the output capped at %d", len(prompt), TestOutputMaxChars)
+	}
+}